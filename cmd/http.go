@@ -1,11 +1,21 @@
 package cmd
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"test-lbc/grpc"
 	"test-lbc/http"
+	"test-lbc/pkg"
+	"test-lbc/pkg/store"
+	"test-lbc/tracing"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -22,29 +32,228 @@ var httpCmd = &cobra.Command{
 var (
 	bindAddr           string
 	prometheusBindAddr string
-	sqlHost            string
-	sqlDB              string
+	grpcBindAddr       string
+	migrateOnStart     bool
+	drainTimeout       time.Duration
+	statsFlushInterval time.Duration
+	statsBatchSize     int
+	maxBatchSize       int
+	rateLimits         map[string]string
+	authFailureLimit   string
+	trustedProxies     []string
+	wsAllowedOrigins   []string
+	traceExporter      string
+	traceEndpoint      string
+	traceSampleRatio   float64
 )
 
+// defaultRateLimits limit the routes computing sequences. A batch computes up
+// to --max-batch-size of them, and a stream holds its connection until the
+// end of its sequence, hence their lower rates.
+var defaultRateLimits = map[string]string{
+	"/fizzbuzz/run":       "20/s:40",
+	"/fizzbuzz/batch":     "2/s:4",
+	"/fizzbuzz/stream":    "5/s:10",
+	"/fizzbuzz/stream/ws": "5/s:10",
+}
+
 func init() {
 	httpCmd.Flags().StringVarP(&bindAddr, "bind-addr", "b", ":8080", "Http port")
 	httpCmd.Flags().StringVarP(&prometheusBindAddr, "prometheus-bind-addr", "p", ":2112", "prometheus metrics port")
-	httpCmd.PersistentFlags().StringVarP(&sqlHost, "mysql-host", "H", "localhost", "MySQL host")
-	httpCmd.PersistentFlags().StringVarP(&sqlDB, "mysql-db", "d", "", "MySQL database")
-
-	httpCmd.MarkPersistentFlagRequired("mysql-db")
+	httpCmd.Flags().StringVar(&grpcBindAddr, "grpc-bind-addr", ":50051", "gRPC port, empty to disable the gRPC server")
+	httpCmd.Flags().DurationVar(&drainTimeout, "drain-timeout", 15*time.Second, "Maximum time to wait for the in-flight requests on shutdown")
+	httpCmd.Flags().BoolVar(&migrateOnStart, "migrate", false, "Apply the pending migrations of the mysql store at startup")
+	httpCmd.Flags().DurationVar(&statsFlushInterval, "stats-flush-interval", time.Second, "Interval between two flushes of the stats, 0 to save them synchronously")
+	httpCmd.Flags().IntVar(&statsBatchSize, "stats-batch-size", 1000, "Number of waiting stats increments triggering a flush")
+	httpCmd.Flags().IntVar(&maxBatchSize, "max-batch-size", 100, "Maximum number of requests of a batch")
+	httpCmd.Flags().StringToStringVar(&rateLimits, "rate-limit", defaultRateLimits, "Rate limits of every client by route, as <route>=<n>/<period>[:<burst>] or <route>=off, the other routes keeping their default")
+	httpCmd.Flags().StringVar(&authFailureLimit, "auth-failure-limit", "10/m", "Rate limit of the rejected API keys of every client IP, as <n>/<period>[:<burst>] or off")
+	httpCmd.Flags().StringSliceVar(&trustedProxies, "trusted-proxies", nil, "Addresses or CIDRs of the proxies whose X-Forwarded-For header gives the client IP")
+	httpCmd.Flags().StringSliceVar(&wsAllowedOrigins, "ws-allowed-origins", nil, "Origins of the pages allowed to open a WebSocket besides the ones of the API host, such as https://app.example.com, or * for any")
+	httpCmd.Flags().StringVar(&traceExporter, "trace-exporter", tracing.ExporterNone, fmt.Sprintf("Exporter of the traces, one of %v", tracing.Exporters))
+	httpCmd.Flags().StringVar(&traceEndpoint, "trace-endpoint", "localhost:4317", "Address of the OTLP gRPC collector of the otlp trace exporter")
+	httpCmd.Flags().Float64Var(&traceSampleRatio, "trace-sample-ratio", 1, "Ratio of the traces recorded, when the caller did not decide")
 }
 
 func startHttpServer(cmd *cobra.Command, args []string) {
-	db, err := getDB(sqlHost, sqlDB)
+	if statsFlushInterval > 0 && statsBatchSize < 1 {
+		fatal("--stats-batch-size must be positive")
+	}
+	if maxBatchSize < 1 {
+		fatal("--max-batch-size must be positive")
+	}
+	for route, limitStr := range defaultRateLimits {
+		if _, ok := rateLimits[route]; !ok {
+			rateLimits[route] = limitStr
+		}
+	}
+	limits := map[string]http.RateLimit{}
+	for route, limitStr := range rateLimits {
+		if limitStr == "off" {
+			continue
+		}
+		limit, err := http.ParseRateLimit(limitStr)
+		if err != nil {
+			fatal("invalid --rate-limit", "route", route, "error", err)
+		}
+		limits[route] = limit
+	}
+	var authLimit *http.RateLimit
+	if authFailureLimit != "off" {
+		limit, err := http.ParseRateLimit(authFailureLimit)
+		if err != nil {
+			fatal("invalid --auth-failure-limit", "error", err)
+		}
+		authLimit = &limit
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    traceExporter,
+		Endpoint:    traceEndpoint,
+		SampleRatio: traceSampleRatio,
+	})
 	if err != nil {
-		log.Fatal(err)
+		fatal("failed to set up tracing", "error", err)
 	}
 
-	err = http.New(db, bindAddr, prometheusBindAddr).Start()
+	statsStore, err := getStore(storeDSN)
 	if err != nil {
-		log.Fatal(err)
+		fatal("failed to open stats store", "error", err)
+	}
+	var (
+		keys      pkg.KeyStore
+		closeKeys = func() error { return nil }
+	)
+	switch {
+	case apiKeysDSN == "db":
+		// the keys share the connections of the stats store, closed with it
+		db := storeDB(statsStore)
+		if db == nil {
+			fatal("failed to open api keys store", "error", fmt.Errorf("store %q has no database", storeDSN))
+		}
+		keys = store.NewSQLKeys(db)
+	case apiKeysDSN != "":
+		if keys, closeKeys, err = getKeyStore(apiKeysDSN); err != nil {
+			fatal("failed to open api keys store", "error", err)
+		}
+	default:
+		slog.Warn("no --api-keys: authentication is disabled")
+	}
+	dbs := map[string]*sql.DB{}
+	if db := storeDB(statsStore); db != nil {
+		dbs["stats"] = db
+	}
+	if statsFlushInterval > 0 {
+		statsStore = store.NewBatcher(statsStore, statsFlushInterval, statsBatchSize)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err = runServers(ctx, statsStore, keys, limits, authLimit, dbs)
+	stop()
+
+	// once the requests are drained: flush the waiting stats and close the database
+	if err := statsStore.Close(); err != nil {
+		slog.Error("failed to close stats store", "error", err)
+	}
+	if err := closeKeys(); err != nil {
+		slog.Error("failed to close api keys store", "error", err)
+	}
+	// the spans of the last flush included
+	tracingCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	if err := shutdownTracing(tracingCtx); err != nil {
+		slog.Error("failed to export the last traces", "error", err)
+	}
+	cancel()
+	if err != nil {
+		fatal("failed to serve", "error", err)
+	}
+}
+
+// fatal logs the error and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// runServers runs the http server, and the gRPC one when its address is set,
+// until ctx is done or one of them fails, which stops the other one.
+func runServers(ctx context.Context, statsStore pkg.StatsStore, keys pkg.KeyStore, limits map[string]http.RateLimit, authLimit *http.RateLimit, dbs map[string]*sql.DB) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	runs := []func(context.Context) error{
+		http.New(statsStore, http.Config{
+			BindAddr:           bindAddr,
+			PrometheusBindAddr: prometheusBindAddr,
+			DrainTimeout:       drainTimeout,
+			MaxBatchSize:       maxBatchSize,
+			RateLimits:         limits,
+			AuthFailureLimit:   authLimit,
+			TrustedProxies:     trustedProxies,
+			WSAllowedOrigins:   wsAllowedOrigins,
+			Keys:               keys,
+			DBs:                dbs,
+		}).Run,
+	}
+	if grpcBindAddr != "" {
+		runs = append(runs, grpc.New(pkg.NewFizzBuzzService(statsStore), keys, grpcBindAddr, drainTimeout).Run)
 	}
+
+	errc := make(chan error, len(runs))
+	for _, run := range runs {
+		go func() {
+			err := run(ctx)
+			cancel()
+			errc <- err
+		}()
+	}
+
+	var err error
+	for range runs {
+		err = errors.Join(err, <-errc)
+	}
+
+	return err
+}
+
+func getStore(storeDSN string) (pkg.StatsStore, error) {
+	switch {
+	case storeDSN == "mysql":
+		db, err := getMySQLDB()
+		if err != nil {
+			return nil, err
+		}
+		if migrateOnStart {
+			if err := migrateUp(context.Background(), db, store.DialectMySQL, 0); err != nil {
+				db.Close()
+				return nil, err
+			}
+		}
+		return store.NewMySQL(db), nil
+	case strings.HasPrefix(storeDSN, "sqlite:"):
+		return store.OpenSQLite(strings.TrimPrefix(storeDSN, "sqlite:"))
+	case storeDSN == "memory":
+		return store.NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown store %q", storeDSN)
+	}
+}
+
+// storeDB returns the database of a SQL store, nil for the other stores.
+func storeDB(s any) *sql.DB {
+	if s, ok := s.(interface{ DB() *sql.DB }); ok {
+		return s.DB()
+	}
+
+	return nil
+}
+
+func getMySQLDB() (*sql.DB, error) {
+	if sqlDB == "" {
+		return nil, errors.New("--mysql-db is required by the mysql store")
+	}
+
+	return getDB(sqlHost, sqlDB)
 }
 
 func getDB(sqlHost, sqlDB string) (*sql.DB, error) {
@@ -56,6 +265,9 @@ func getDB(sqlHost, sqlDB string) (*sql.DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to mysql db : %s", err.Error())
 	}
+	db.SetMaxOpenConns(sqlMaxOpenConns)
+	db.SetMaxIdleConns(sqlMaxIdleConns)
+	db.SetConnMaxLifetime(sqlConnMaxLifetime)
 
 	return db, nil
 }
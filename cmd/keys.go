@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"test-lbc/pkg"
+	"test-lbc/pkg/models"
+	"test-lbc/pkg/store"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the API keys",
+	Long:  "Create, list or revoke the API keys of the store given by --api-keys",
+}
+
+var (
+	keyName   string
+	keyScopes []string
+)
+
+var keysCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an API key, printed once",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scopes := make([]models.Scope, len(keyScopes))
+		for i, scope := range keyScopes {
+			scopes[i] = models.Scope(scope)
+		}
+		key, record, err := pkg.NewAPIKey(keyName, scopes)
+		if err != nil {
+			return err
+		}
+
+		keys, closeKeys, err := getKeyStore(apiKeysDSN)
+		if err != nil {
+			return err
+		}
+		defer closeKeys()
+		if err := keys.CreateKey(record); err != nil {
+			return err
+		}
+
+		// only the hash of the key is kept: it can not be printed again
+		fmt.Fprintf(cmd.OutOrStdout(), "id:  %s\nkey: %s\n", record.ID, key)
+
+		return nil
+	},
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the API keys",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys, closeKeys, err := getKeyStore(apiKeysDSN)
+		if err != nil {
+			return err
+		}
+		defer closeKeys()
+		records, err := keys.Keys()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tSCOPES\tCREATED AT\tREVOKED AT")
+		for _, record := range records {
+			scopes := make([]string, len(record.Scopes))
+			for i, scope := range record.Scopes {
+				scopes[i] = string(scope)
+			}
+			revokedAt := "-"
+			if record.RevokedAt != nil {
+				revokedAt = record.RevokedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", record.ID, record.Name, strings.Join(scopes, ","), record.CreatedAt.Format(time.DateTime), revokedAt)
+		}
+
+		return w.Flush()
+	},
+}
+
+var keysRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revoke an API key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys, closeKeys, err := getKeyStore(apiKeysDSN)
+		if err != nil {
+			return err
+		}
+		defer closeKeys()
+
+		return keys.RevokeKey(args[0], time.Now())
+	},
+}
+
+func init() {
+	keysCreateCmd.Flags().StringVar(&keyName, "name", "", "Name of the owner of the key")
+	keysCreateCmd.Flags().StringSliceVar(&keyScopes, "scope", nil, fmt.Sprintf("Scopes granted by the key, among %v", models.Scopes))
+	keysCreateCmd.MarkFlagRequired("name")
+	keysCreateCmd.MarkFlagRequired("scope")
+
+	keysCmd.AddCommand(keysCreateCmd)
+	keysCmd.AddCommand(keysListCmd)
+	keysCmd.AddCommand(keysRevokeCmd)
+}
+
+// getKeyStore opens the API keys store, and returns the function releasing it.
+// The keys of the database share the schema of the stats store: the sqlite
+// one is migrated, as when it is opened as a stats store. The http-server
+// rather shares the database of its stats store.
+func getKeyStore(apiKeysDSN string) (pkg.KeyStore, func() error, error) {
+	switch {
+	case strings.HasPrefix(apiKeysDSN, "file:"):
+		keys, err := store.OpenFileKeys(strings.TrimPrefix(apiKeysDSN, "file:"))
+		return keys, func() error { return nil }, err
+	case apiKeysDSN == "db":
+		db, dialect, err := getMigrateDB()
+		if err != nil {
+			return nil, nil, err
+		}
+		if dialect == store.DialectSQLite {
+			if err := migrateUp(context.Background(), db, dialect, 0); err != nil {
+				db.Close()
+				return nil, nil, err
+			}
+		}
+		return store.NewSQLKeys(db), db.Close, nil
+	case apiKeysDSN == "":
+		return nil, nil, errors.New("--api-keys is required")
+	default:
+		return nil, nil, fmt.Errorf("unknown api keys store %q", apiKeysDSN)
+	}
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"test-lbc/pkg/store"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate the stats store schema",
+	Long:  "Apply, revert or list the schema migrations of the mysql or sqlite stats store",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up [version]",
+	Short: "Apply the pending migrations, up to version when given",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, err := migrationVersion(args)
+		if err != nil {
+			return err
+		}
+		db, dialect, err := getMigrateDB()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		return migrateUp(cmd.Context(), db, dialect, target)
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down [version]",
+	Short: "Revert the migrations above version (0 for all), the last applied one by default",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, dialect, err := getMigrateDB()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		migrator, err := store.NewMigrator(db, dialect)
+		if err != nil {
+			return err
+		}
+
+		target := -1
+		if len(args) > 0 {
+			if target, err = migrationVersion(args); err != nil {
+				return err
+			}
+		} else {
+			statuses, err := migrator.Status(cmd.Context())
+			if err != nil {
+				return err
+			}
+			// the version applied before the last one
+			applied := 0
+			for _, status := range statuses {
+				if status.Applied {
+					target, applied = applied, status.Version
+				}
+			}
+			if target < 0 {
+				slog.Info("no migration to revert")
+				return nil
+			}
+		}
+
+		reverted, err := migrator.Down(cmd.Context(), target)
+		for _, migration := range reverted {
+			slog.Info("reverted migration", "version", migration.Version, "name", migration.Name)
+		}
+
+		return err
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List the migrations and whether they are applied",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, dialect, err := getMigrateDB()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		migrator, err := store.NewMigrator(db, dialect)
+		if err != nil {
+			return err
+		}
+		statuses, err := migrator.Status(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			applied := "pending"
+			if status.Applied {
+				applied = "applied at " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%04d_%s\t%s\n", status.Version, status.Name, applied)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+}
+
+// getMigrateDB opens the database of the stats store, without migrating it.
+func getMigrateDB() (*sql.DB, store.Dialect, error) {
+	switch {
+	case storeDSN == "mysql":
+		db, err := getMySQLDB()
+		return db, store.DialectMySQL, err
+	case strings.HasPrefix(storeDSN, "sqlite:"):
+		db, err := store.OpenSQLiteDB(strings.TrimPrefix(storeDSN, "sqlite:"))
+		return db, store.DialectSQLite, err
+	default:
+		return nil, "", fmt.Errorf("store %q has no migrations", storeDSN)
+	}
+}
+
+func migrationVersion(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(args[0])
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid migration version %q", args[0])
+	}
+
+	return version, nil
+}
+
+func migrateUp(ctx context.Context, db *sql.DB, dialect store.Dialect, target int) error {
+	migrator, err := store.NewMigrator(db, dialect)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(ctx, target)
+	for _, migration := range applied {
+		slog.Info("applied migration", "version", migration.Version, "name", migration.Name)
+	}
+
+	return err
+}
@@ -1,18 +1,62 @@
 package cmd
 
 import (
+	"fmt"
+	"log/slog"
+	"os"
+	"test-lbc/logging"
+	"time"
+
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "test-lbc",
 	Short: "roo cmd",
+	// a failing command prints its error, not the usage
+	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		logger, err := logging.New(os.Stderr, logFormat, logLevel)
+		if err != nil {
+			return err
+		}
+		// the log package writes through the logger too
+		slog.SetDefault(logger)
+
+		return nil
+	},
 }
 
 func Execute() error {
 	return rootCmd.Execute()
 }
 
+var (
+	storeDSN   string
+	sqlHost    string
+	sqlDB      string
+	apiKeysDSN string
+	logFormat  string
+	logLevel   string
+
+	sqlMaxOpenConns    int
+	sqlMaxIdleConns    int
+	sqlConnMaxLifetime time.Duration
+)
+
 func init() {
+	rootCmd.PersistentFlags().StringVarP(&storeDSN, "store", "s", "mysql", `Stats store: "mysql", "sqlite:<path>" or "memory"`)
+	rootCmd.PersistentFlags().StringVarP(&sqlHost, "mysql-host", "H", "localhost", "MySQL host")
+	rootCmd.PersistentFlags().StringVarP(&sqlDB, "mysql-db", "d", "", "MySQL database (required by the mysql store)")
+	rootCmd.PersistentFlags().IntVar(&sqlMaxOpenConns, "mysql-max-open-conns", 0, "Maximum number of open connections to MySQL, 0 for unlimited")
+	rootCmd.PersistentFlags().IntVar(&sqlMaxIdleConns, "mysql-max-idle-conns", 2, "Maximum number of idle connections to MySQL, 0 to keep none")
+	rootCmd.PersistentFlags().DurationVar(&sqlConnMaxLifetime, "mysql-conn-max-lifetime", 0, "Maximum time a MySQL connection is reused, 0 for no limit")
+	rootCmd.PersistentFlags().StringVar(&apiKeysDSN, "api-keys", "", `API keys: "file:<path>", or "db" for the database of the mysql or sqlite store; empty to disable authentication`)
+
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", logging.FormatText, fmt.Sprintf("Log format, one of %v", logging.Formats))
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Minimum level of the logs: debug, info, warn or error")
+
 	rootCmd.AddCommand(httpCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(keysCmd)
 }
@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"test-lbc/grpc/pb"
+	"test-lbc/pkg"
+	"test-lbc/pkg/models"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodScopes are the scopes required by the methods, the other ones, such
+// as the reflection, being public.
+var methodScopes = map[string]models.Scope{
+	pb.FizzBuzzService_Run_FullMethodName:              models.ScopeRun,
+	pb.FizzBuzzService_RunStream_FullMethodName:        models.ScopeRun,
+	pb.FizzBuzzService_GetMostRequested_FullMethodName: models.ScopeStatsRead,
+}
+
+func unaryAuth(keys pkg.KeyStore) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, keys, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(keys pkg.KeyStore) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(stream.Context(), keys, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// authorize checks that the API key of the authorization bearer token or
+// x-api-key metadata grants the scope of the method, as the http API does.
+func authorize(ctx context.Context, keys pkg.KeyStore, method string) error {
+	scope, ok := methodScopes[method]
+	if !ok {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	var key string
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		key = values[0]
+	}
+	if values := md.Get("authorization"); len(values) > 0 {
+		if token, found := strings.CutPrefix(values[0], "Bearer "); found {
+			key = strings.TrimSpace(token)
+		}
+	}
+	if key == "" {
+		return status.Error(codes.Unauthenticated, "an api key is required")
+	}
+
+	record, err := keys.KeyByHash(pkg.HashAPIKey(key))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to authenticate", "error", err)
+		return status.Error(codes.Internal, "failed to check api key")
+	}
+	if record == nil || record.RevokedAt != nil {
+		return status.Error(codes.Unauthenticated, "invalid api key")
+	}
+	if !record.HasScope(scope) {
+		return status.Errorf(codes.PermissionDenied, "api key lacks the %q scope", scope)
+	}
+
+	return nil
+}
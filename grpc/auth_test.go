@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"context"
+	"path/filepath"
+	"test-lbc/grpc/pb"
+	"test-lbc/pkg"
+	"test-lbc/pkg/models"
+	"test-lbc/pkg/store"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestAuth(t *testing.T) {
+	keys, err := store.OpenFileKeys(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	newKey := func(scopes ...models.Scope) (string, string) {
+		key, record, err := pkg.NewAPIKey("test", scopes)
+		if err == nil {
+			err = keys.CreateKey(record)
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return key, record.ID
+	}
+	runKey, _ := newKey(models.ScopeRun)
+	revokedKey, revokedID := newKey(models.ScopeRun, models.ScopeStatsRead)
+	if err := keys.RevokeKey(revokedID, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := newClient(t, grpc.UnaryInterceptor(unaryAuth(keys)), grpc.StreamInterceptor(streamAuth(keys)))
+	req := &pb.RunRequest{Limit: proto.Int64(3), Rules: []*pb.Rule{{Divisor: 3, Word: "fizz"}}}
+	withKey := func(kv ...string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), kv...)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		call     func() error
+		expected codes.Code
+	}{
+		{"Without key", func() error { _, err := client.Run(context.Background(), req); return err }, codes.Unauthenticated},
+		{"Bearer token", func() error { _, err := client.Run(withKey("authorization", "Bearer "+runKey), req); return err }, codes.OK},
+		{"X-API-Key metadata", func() error { _, err := client.Run(withKey("x-api-key", runKey), req); return err }, codes.OK},
+		{"Revoked key", func() error { _, err := client.Run(withKey("x-api-key", revokedKey), req); return err }, codes.Unauthenticated},
+		{"Missing scope", func() error {
+			_, err := client.GetMostRequested(withKey("x-api-key", runKey), &pb.GetMostRequestedRequest{})
+			return err
+		}, codes.PermissionDenied},
+		{"Stream without key", func() error {
+			stream, err := client.RunStream(context.Background(), req)
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			return err
+		}, codes.Unauthenticated},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if code := status.Code(tc.call()); code != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, code)
+			}
+		})
+	}
+}
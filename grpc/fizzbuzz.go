@@ -0,0 +1,191 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"test-lbc/grpc/pb"
+	"test-lbc/http/handlers"
+	"test-lbc/pkg"
+	fModels "test-lbc/pkg/models"
+	"test-lbc/prometheus"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxRunValues is the maximum length of a sequence returned at once, the
+// longer ones having to be streamed.
+const maxRunValues = 100000
+
+// modes maps the gRPC modes onto the engine ones, the unspecified mode
+// being defaulted by the validation.
+var modes = map[pb.Mode]fModels.Mode{
+	pb.Mode_MODE_UNSPECIFIED: "",
+	pb.Mode_MODE_CONCAT:      fModels.ModeConcat,
+	pb.Mode_MODE_PRODUCT:     fModels.ModeProduct,
+	pb.Mode_MODE_FIRST:       fModels.ModeFirst,
+	pb.Mode_MODE_LAST:        fModels.ModeLast,
+}
+
+// fizzBuzzServer implements the gRPC API on top of the service of the REST one.
+type fizzBuzzServer struct {
+	pb.UnimplementedFizzBuzzServiceServer
+
+	service handlers.FizzBuzzService
+}
+
+func (s *fizzBuzzServer) Run(ctx context.Context, req *pb.RunRequest) (*pb.RunResponse, error) {
+	prometheus.IncRequest("grpc_run")
+	params, err := getFizzBuzzParams(req)
+	length := 0
+	if err == nil {
+		if length, _ = pkg.Len(params.Start, params.End, params.Step); length > maxRunValues {
+			err = status.Errorf(codes.InvalidArgument, "Run can not return more than %d values, use RunStream", maxRunValues)
+		}
+	}
+	if err != nil {
+		prometheus.IncStats("grpc_run", "error")
+		slog.WarnContext(ctx, "failed to run fizzbuzz", "error", err)
+		return nil, err
+	}
+	prometheus.ObserveRequestedValues("grpc_run", length)
+
+	result, err := s.service.Run(ctx, *params)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to save stats", "error", err)
+		prometheus.IncStats("grpc_run", "error_on_stat_save")
+	} else {
+		prometheus.IncStats("grpc_run", "success")
+	}
+
+	resp := &pb.RunResponse{}
+	for _, value := range result {
+		resp.Values = append(resp.Values, value)
+	}
+
+	return resp, nil
+}
+
+func (s *fizzBuzzServer) RunStream(req *pb.RunRequest, stream grpc.ServerStreamingServer[pb.RunValue]) error {
+	prometheus.IncRequest("grpc_run_stream")
+	params, err := getFizzBuzzParams(req)
+	if err != nil {
+		prometheus.IncStats("grpc_run_stream", "error")
+		slog.WarnContext(stream.Context(), "failed to stream fizzbuzz", "error", err)
+		return err
+	}
+	length, _ := pkg.Len(params.Start, params.End, params.Step)
+	prometheus.ObserveRequestedValues("grpc_run_stream", length)
+
+	result, err := s.service.Run(stream.Context(), *params)
+	if err != nil {
+		slog.ErrorContext(stream.Context(), "failed to save stats", "error", err)
+		prometheus.IncStats("grpc_run_stream", "error_on_stat_save")
+	} else {
+		prometheus.IncStats("grpc_run_stream", "success")
+	}
+
+	// Send fails once the client cancels the call
+	for i, value := range result {
+		if err := stream.Send(&pb.RunValue{Index: int64(i), Value: value}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *fizzBuzzServer) GetMostRequested(ctx context.Context, _ *pb.GetMostRequestedRequest) (*pb.GetMostRequestedResponse, error) {
+	prometheus.IncRequest("grpc_stats")
+	mostRequested, err := s.service.GetMostRequested(ctx)
+	if err != nil {
+		prometheus.IncStats("grpc_stats", "error")
+		slog.ErrorContext(ctx, "failed to retrieve fizzbuzz stats", "error", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	prometheus.IncStats("grpc_stats", "success")
+	resp := &pb.GetMostRequestedResponse{}
+	if mostRequested != nil {
+		resp.Stats = &pb.Stats{
+			Start:     int64(mostRequested.Start),
+			End:       int64(mostRequested.End),
+			Step:      int64(mostRequested.Step),
+			Rules:     toPbRules(mostRequested.Rules),
+			Mode:      toPbMode(mostRequested.Mode),
+			Hits:      int64(mostRequested.Hits),
+			LastHitAt: timestamppb.New(mostRequested.LastHitAt),
+		}
+	}
+
+	return resp, nil
+}
+
+// getFizzBuzzParams validates the request as the REST API does, returning an
+// InvalidArgument status error detailing the violations of the request as a
+// BadRequest, their reason being their upper-cased code.
+func getFizzBuzzParams(req *pb.RunRequest) (*fModels.FizzBuzzParams, error) {
+	mode, ok := modes[req.GetMode()]
+	if !ok {
+		// an unknown mode fails the validation
+		mode = fModels.Mode(req.GetMode().String())
+	}
+	rules := make([]fModels.Rule, len(req.GetRules()))
+	for i, rule := range req.GetRules() {
+		rules[i] = fModels.Rule{Divisor: int(rule.GetDivisor()), Word: rule.GetWord()}
+	}
+
+	params, violations := handlers.ValidateParams(toInt(req.Limit), toInt(req.Start), toInt(req.End), toInt(req.Step), rules, mode)
+	if len(violations) > 0 {
+		var (
+			messages   = make([]string, len(violations))
+			badRequest = &errdetails.BadRequest{}
+		)
+		for i, violation := range violations {
+			messages[i] = violation.Message
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       violation.Field,
+				Description: violation.Message,
+				Reason:      strings.ToUpper(string(violation.Code)),
+			})
+		}
+		st, err := status.New(codes.InvalidArgument, strings.Join(messages, "; ")).WithDetails(badRequest)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, strings.Join(messages, "; "))
+		}
+		return nil, st.Err()
+	}
+
+	return params, nil
+}
+
+func toInt(value *int64) *int {
+	if value == nil {
+		return nil
+	}
+	i := int(*value)
+	return &i
+}
+
+func toPbRules(rules []fModels.Rule) []*pb.Rule {
+	pbRules := make([]*pb.Rule, len(rules))
+	for i, rule := range rules {
+		pbRules[i] = &pb.Rule{Divisor: int64(rule.Divisor), Word: rule.Word}
+	}
+
+	return pbRules
+}
+
+func toPbMode(mode fModels.Mode) pb.Mode {
+	for pbMode, m := range modes {
+		if m == mode && pbMode != pb.Mode_MODE_UNSPECIFIED {
+			return pbMode
+		}
+	}
+
+	return pb.Mode_MODE_UNSPECIFIED
+}
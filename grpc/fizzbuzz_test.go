@@ -0,0 +1,176 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"test-lbc/grpc/pb"
+	"test-lbc/pkg"
+	"test-lbc/pkg/store"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// newClient serves the gRPC API of a memory store in memory, with the server
+// options, and returns a client of it.
+func newClient(t *testing.T, opts ...grpc.ServerOption) pb.FizzBuzzServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(opts...)
+	pb.RegisterFizzBuzzServiceServer(server, &fizzBuzzServer{service: pkg.NewFizzBuzzService(store.NewMemory())})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewFizzBuzzServiceClient(conn)
+}
+
+func TestFizzBuzzServer_Run(t *testing.T) {
+	client := newClient(t)
+	rules := []*pb.Rule{{Divisor: 3, Word: "fizz"}, {Divisor: 5, Word: "buzz"}}
+
+	testCases := []struct {
+		name         string
+		req          *pb.RunRequest
+		expected     []string
+		expectedCode codes.Code
+	}{
+		{
+			name:     "Limit",
+			req:      &pb.RunRequest{Limit: proto.Int64(5), Rules: rules},
+			expected: []string{"1", "2", "fizz", "4", "buzz"},
+		},
+		{
+			name:     "Range and mode",
+			req:      &pb.RunRequest{Start: proto.Int64(15), End: proto.Int64(9), Step: proto.Int64(-3), Rules: rules, Mode: pb.Mode_MODE_LAST},
+			expected: []string{"buzz", "fizz", "fizz"},
+		},
+		{
+			name:         "Missing range",
+			req:          &pb.RunRequest{Rules: rules},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "Unknown mode",
+			req:          &pb.RunRequest{Limit: proto.Int64(5), Rules: rules, Mode: pb.Mode(42)},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "Too long",
+			req:          &pb.RunRequest{Limit: proto.Int64(maxRunValues + 1), Rules: rules},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := client.Run(context.Background(), tc.req)
+			if code := status.Code(err); code != tc.expectedCode {
+				t.Fatalf("expected code %v, got %v", tc.expectedCode, err)
+			}
+			if err == nil && !reflect.DeepEqual(resp.GetValues(), tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, resp.GetValues())
+			}
+		})
+	}
+
+	t.Run("Violations details", func(t *testing.T) {
+		_, err := client.Run(context.Background(), &pb.RunRequest{Limit: proto.Int64(-1), Rules: rules})
+		var badRequest *errdetails.BadRequest
+		for _, detail := range status.Convert(err).Details() {
+			if detail, ok := detail.(*errdetails.BadRequest); ok {
+				badRequest = detail
+			}
+		}
+		violations := badRequest.GetFieldViolations()
+		if len(violations) != 1 || violations[0].GetField() != "limit" || violations[0].GetReason() != "LIMIT_OUT_OF_RANGE" {
+			t.Errorf("expected a LIMIT_OUT_OF_RANGE violation of limit, got %v", violations)
+		}
+	})
+}
+
+func TestFizzBuzzServer_RunStream(t *testing.T) {
+	client := newClient(t)
+
+	stream, err := client.RunStream(context.Background(), &pb.RunRequest{Limit: proto.Int64(3), Rules: []*pb.Rule{{Divisor: 3, Word: "fizz"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var values []*pb.RunValue
+	for {
+		value, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		values = append(values, value)
+	}
+
+	expected := []*pb.RunValue{{Index: 0, Value: "1"}, {Index: 1, Value: "2"}, {Index: 2, Value: "fizz"}}
+	if len(values) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, values)
+	}
+	for i := range values {
+		if !proto.Equal(values[i], expected[i]) {
+			t.Errorf("expected %v, got %v", expected[i], values[i])
+		}
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		stream, err := client.RunStream(context.Background(), &pb.RunRequest{Limit: proto.Int64(3)})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected an invalid argument, got %v", err)
+		}
+	})
+}
+
+func TestFizzBuzzServer_GetMostRequested(t *testing.T) {
+	client := newClient(t)
+
+	resp, err := client.GetMostRequested(context.Background(), &pb.GetMostRequestedRequest{})
+	if err != nil || resp.GetStats() != nil {
+		t.Fatalf("expected no stats, got %v, %v", resp, err)
+	}
+
+	req := &pb.RunRequest{Limit: proto.Int64(10), Rules: []*pb.Rule{{Divisor: 2, Word: "even"}}, Mode: pb.Mode_MODE_PRODUCT}
+	for range 2 {
+		if _, err := client.Run(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	resp, err = client.GetMostRequested(context.Background(), &pb.GetMostRequestedRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stats := resp.GetStats()
+	if stats.GetStart() != 1 || stats.GetEnd() != 10 || stats.GetStep() != 1 || stats.GetHits() != 2 ||
+		stats.GetMode() != pb.Mode_MODE_PRODUCT || len(stats.GetRules()) != 1 || stats.GetLastHitAt() == nil {
+		t.Errorf("expected 1 to 10 requested twice, got %v", stats)
+	}
+}
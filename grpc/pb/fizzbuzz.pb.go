@@ -0,0 +1,617 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: fizzbuzz.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Mode tells how the words of several rules matching a same number are
+// combined.
+type Mode int32
+
+const (
+	// MODE_UNSPECIFIED is MODE_CONCAT.
+	Mode_MODE_UNSPECIFIED Mode = 0
+	// MODE_CONCAT concatenates the words of every matching rule.
+	Mode_MODE_CONCAT Mode = 1
+	// MODE_PRODUCT replaces the multiples of the product of several divisors
+	// by their words, the largest set of rules having priority.
+	Mode_MODE_PRODUCT Mode = 2
+	// MODE_FIRST keeps the word of the first matching rule only.
+	Mode_MODE_FIRST Mode = 3
+	// MODE_LAST keeps the word of the last matching rule only.
+	Mode_MODE_LAST Mode = 4
+)
+
+// Enum value maps for Mode.
+var (
+	Mode_name = map[int32]string{
+		0: "MODE_UNSPECIFIED",
+		1: "MODE_CONCAT",
+		2: "MODE_PRODUCT",
+		3: "MODE_FIRST",
+		4: "MODE_LAST",
+	}
+	Mode_value = map[string]int32{
+		"MODE_UNSPECIFIED": 0,
+		"MODE_CONCAT":      1,
+		"MODE_PRODUCT":     2,
+		"MODE_FIRST":       3,
+		"MODE_LAST":        4,
+	}
+)
+
+func (x Mode) Enum() *Mode {
+	p := new(Mode)
+	*p = x
+	return p
+}
+
+func (x Mode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Mode) Descriptor() protoreflect.EnumDescriptor {
+	return file_fizzbuzz_proto_enumTypes[0].Descriptor()
+}
+
+func (Mode) Type() protoreflect.EnumType {
+	return &file_fizzbuzz_proto_enumTypes[0]
+}
+
+func (x Mode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Mode.Descriptor instead.
+func (Mode) EnumDescriptor() ([]byte, []int) {
+	return file_fizzbuzz_proto_rawDescGZIP(), []int{0}
+}
+
+// Rule replaces the multiples of divisor by word.
+type Rule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Divisor       int64                  `protobuf:"varint,1,opt,name=divisor,proto3" json:"divisor,omitempty"`
+	Word          string                 `protobuf:"bytes,2,opt,name=word,proto3" json:"word,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rule) Reset() {
+	*x = Rule{}
+	mi := &file_fizzbuzz_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rule) ProtoMessage() {}
+
+func (x *Rule) ProtoReflect() protoreflect.Message {
+	mi := &file_fizzbuzz_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rule.ProtoReflect.Descriptor instead.
+func (*Rule) Descriptor() ([]byte, []int) {
+	return file_fizzbuzz_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Rule) GetDivisor() int64 {
+	if x != nil {
+		return x.Divisor
+	}
+	return 0
+}
+
+func (x *Rule) GetWord() string {
+	if x != nil {
+		return x.Word
+	}
+	return ""
+}
+
+// RunRequest is a sequence from 1 to limit, or from start to end (both
+// included), by step.
+type RunRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Limit *int64                 `protobuf:"varint,1,opt,name=limit,proto3,oneof" json:"limit,omitempty"`
+	Start *int64                 `protobuf:"varint,2,opt,name=start,proto3,oneof" json:"start,omitempty"`
+	End   *int64                 `protobuf:"varint,3,opt,name=end,proto3,oneof" json:"end,omitempty"`
+	// step defaults to 1, or -1 when start is greater than end.
+	Step *int64 `protobuf:"varint,4,opt,name=step,proto3,oneof" json:"step,omitempty"`
+	// rules are applied in order, at most 10 of them.
+	Rules         []*Rule `protobuf:"bytes,5,rep,name=rules,proto3" json:"rules,omitempty"`
+	Mode          Mode    `protobuf:"varint,6,opt,name=mode,proto3,enum=fizzbuzz.v1.Mode" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunRequest) Reset() {
+	*x = RunRequest{}
+	mi := &file_fizzbuzz_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunRequest) ProtoMessage() {}
+
+func (x *RunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fizzbuzz_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunRequest.ProtoReflect.Descriptor instead.
+func (*RunRequest) Descriptor() ([]byte, []int) {
+	return file_fizzbuzz_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RunRequest) GetLimit() int64 {
+	if x != nil && x.Limit != nil {
+		return *x.Limit
+	}
+	return 0
+}
+
+func (x *RunRequest) GetStart() int64 {
+	if x != nil && x.Start != nil {
+		return *x.Start
+	}
+	return 0
+}
+
+func (x *RunRequest) GetEnd() int64 {
+	if x != nil && x.End != nil {
+		return *x.End
+	}
+	return 0
+}
+
+func (x *RunRequest) GetStep() int64 {
+	if x != nil && x.Step != nil {
+		return *x.Step
+	}
+	return 0
+}
+
+func (x *RunRequest) GetRules() []*Rule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *RunRequest) GetMode() Mode {
+	if x != nil {
+		return x.Mode
+	}
+	return Mode_MODE_UNSPECIFIED
+}
+
+type RunResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []string               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunResponse) Reset() {
+	*x = RunResponse{}
+	mi := &file_fizzbuzz_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunResponse) ProtoMessage() {}
+
+func (x *RunResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fizzbuzz_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunResponse.ProtoReflect.Descriptor instead.
+func (*RunResponse) Descriptor() ([]byte, []int) {
+	return file_fizzbuzz_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RunResponse) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// RunValue is a value of a streamed sequence along with its index, from 0.
+type RunValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int64                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunValue) Reset() {
+	*x = RunValue{}
+	mi := &file_fizzbuzz_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunValue) ProtoMessage() {}
+
+func (x *RunValue) ProtoReflect() protoreflect.Message {
+	mi := &file_fizzbuzz_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunValue.ProtoReflect.Descriptor instead.
+func (*RunValue) Descriptor() ([]byte, []int) {
+	return file_fizzbuzz_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RunValue) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *RunValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type GetMostRequestedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMostRequestedRequest) Reset() {
+	*x = GetMostRequestedRequest{}
+	mi := &file_fizzbuzz_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMostRequestedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMostRequestedRequest) ProtoMessage() {}
+
+func (x *GetMostRequestedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fizzbuzz_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMostRequestedRequest.ProtoReflect.Descriptor instead.
+func (*GetMostRequestedRequest) Descriptor() ([]byte, []int) {
+	return file_fizzbuzz_proto_rawDescGZIP(), []int{4}
+}
+
+type GetMostRequestedResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// stats is not set when nothing was requested yet.
+	Stats         *Stats `protobuf:"bytes,1,opt,name=stats,proto3" json:"stats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMostRequestedResponse) Reset() {
+	*x = GetMostRequestedResponse{}
+	mi := &file_fizzbuzz_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMostRequestedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMostRequestedResponse) ProtoMessage() {}
+
+func (x *GetMostRequestedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fizzbuzz_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMostRequestedResponse.ProtoReflect.Descriptor instead.
+func (*GetMostRequestedResponse) Descriptor() ([]byte, []int) {
+	return file_fizzbuzz_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetMostRequestedResponse) GetStats() *Stats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+// Stats are the hits of a request.
+type Stats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         int64                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           int64                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	Step          int64                  `protobuf:"varint,3,opt,name=step,proto3" json:"step,omitempty"`
+	Rules         []*Rule                `protobuf:"bytes,4,rep,name=rules,proto3" json:"rules,omitempty"`
+	Mode          Mode                   `protobuf:"varint,5,opt,name=mode,proto3,enum=fizzbuzz.v1.Mode" json:"mode,omitempty"`
+	Hits          int64                  `protobuf:"varint,6,opt,name=hits,proto3" json:"hits,omitempty"`
+	LastHitAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=last_hit_at,json=lastHitAt,proto3" json:"last_hit_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Stats) Reset() {
+	*x = Stats{}
+	mi := &file_fizzbuzz_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stats) ProtoMessage() {}
+
+func (x *Stats) ProtoReflect() protoreflect.Message {
+	mi := &file_fizzbuzz_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stats.ProtoReflect.Descriptor instead.
+func (*Stats) Descriptor() ([]byte, []int) {
+	return file_fizzbuzz_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Stats) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *Stats) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *Stats) GetStep() int64 {
+	if x != nil {
+		return x.Step
+	}
+	return 0
+}
+
+func (x *Stats) GetRules() []*Rule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *Stats) GetMode() Mode {
+	if x != nil {
+		return x.Mode
+	}
+	return Mode_MODE_UNSPECIFIED
+}
+
+func (x *Stats) GetHits() int64 {
+	if x != nil {
+		return x.Hits
+	}
+	return 0
+}
+
+func (x *Stats) GetLastHitAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastHitAt
+	}
+	return nil
+}
+
+var File_fizzbuzz_proto protoreflect.FileDescriptor
+
+const file_fizzbuzz_proto_rawDesc = "" +
+	"\n" +
+	"\x0efizzbuzz.proto\x12\vfizzbuzz.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"4\n" +
+	"\x04Rule\x12\x18\n" +
+	"\adivisor\x18\x01 \x01(\x03R\adivisor\x12\x12\n" +
+	"\x04word\x18\x02 \x01(\tR\x04word\"\xe7\x01\n" +
+	"\n" +
+	"RunRequest\x12\x19\n" +
+	"\x05limit\x18\x01 \x01(\x03H\x00R\x05limit\x88\x01\x01\x12\x19\n" +
+	"\x05start\x18\x02 \x01(\x03H\x01R\x05start\x88\x01\x01\x12\x15\n" +
+	"\x03end\x18\x03 \x01(\x03H\x02R\x03end\x88\x01\x01\x12\x17\n" +
+	"\x04step\x18\x04 \x01(\x03H\x03R\x04step\x88\x01\x01\x12'\n" +
+	"\x05rules\x18\x05 \x03(\v2\x11.fizzbuzz.v1.RuleR\x05rules\x12%\n" +
+	"\x04mode\x18\x06 \x01(\x0e2\x11.fizzbuzz.v1.ModeR\x04modeB\b\n" +
+	"\x06_limitB\b\n" +
+	"\x06_startB\x06\n" +
+	"\x04_endB\a\n" +
+	"\x05_step\"%\n" +
+	"\vRunResponse\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\tR\x06values\"6\n" +
+	"\bRunValue\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x03R\x05index\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\"\x19\n" +
+	"\x17GetMostRequestedRequest\"D\n" +
+	"\x18GetMostRequestedResponse\x12(\n" +
+	"\x05stats\x18\x01 \x01(\v2\x12.fizzbuzz.v1.StatsR\x05stats\"\xe3\x01\n" +
+	"\x05Stats\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x03R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x03R\x03end\x12\x12\n" +
+	"\x04step\x18\x03 \x01(\x03R\x04step\x12'\n" +
+	"\x05rules\x18\x04 \x03(\v2\x11.fizzbuzz.v1.RuleR\x05rules\x12%\n" +
+	"\x04mode\x18\x05 \x01(\x0e2\x11.fizzbuzz.v1.ModeR\x04mode\x12\x12\n" +
+	"\x04hits\x18\x06 \x01(\x03R\x04hits\x12:\n" +
+	"\vlast_hit_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tlastHitAt*^\n" +
+	"\x04Mode\x12\x14\n" +
+	"\x10MODE_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vMODE_CONCAT\x10\x01\x12\x10\n" +
+	"\fMODE_PRODUCT\x10\x02\x12\x0e\n" +
+	"\n" +
+	"MODE_FIRST\x10\x03\x12\r\n" +
+	"\tMODE_LAST\x10\x042\xeb\x01\n" +
+	"\x0fFizzBuzzService\x128\n" +
+	"\x03Run\x12\x17.fizzbuzz.v1.RunRequest\x1a\x18.fizzbuzz.v1.RunResponse\x12=\n" +
+	"\tRunStream\x12\x17.fizzbuzz.v1.RunRequest\x1a\x15.fizzbuzz.v1.RunValue0\x01\x12_\n" +
+	"\x10GetMostRequested\x12$.fizzbuzz.v1.GetMostRequestedRequest\x1a%.fizzbuzz.v1.GetMostRequestedResponseB\x12Z\x10test-lbc/grpc/pbb\x06proto3"
+
+var (
+	file_fizzbuzz_proto_rawDescOnce sync.Once
+	file_fizzbuzz_proto_rawDescData []byte
+)
+
+func file_fizzbuzz_proto_rawDescGZIP() []byte {
+	file_fizzbuzz_proto_rawDescOnce.Do(func() {
+		file_fizzbuzz_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_fizzbuzz_proto_rawDesc), len(file_fizzbuzz_proto_rawDesc)))
+	})
+	return file_fizzbuzz_proto_rawDescData
+}
+
+var file_fizzbuzz_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_fizzbuzz_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_fizzbuzz_proto_goTypes = []any{
+	(Mode)(0),                        // 0: fizzbuzz.v1.Mode
+	(*Rule)(nil),                     // 1: fizzbuzz.v1.Rule
+	(*RunRequest)(nil),               // 2: fizzbuzz.v1.RunRequest
+	(*RunResponse)(nil),              // 3: fizzbuzz.v1.RunResponse
+	(*RunValue)(nil),                 // 4: fizzbuzz.v1.RunValue
+	(*GetMostRequestedRequest)(nil),  // 5: fizzbuzz.v1.GetMostRequestedRequest
+	(*GetMostRequestedResponse)(nil), // 6: fizzbuzz.v1.GetMostRequestedResponse
+	(*Stats)(nil),                    // 7: fizzbuzz.v1.Stats
+	(*timestamppb.Timestamp)(nil),    // 8: google.protobuf.Timestamp
+}
+var file_fizzbuzz_proto_depIdxs = []int32{
+	1, // 0: fizzbuzz.v1.RunRequest.rules:type_name -> fizzbuzz.v1.Rule
+	0, // 1: fizzbuzz.v1.RunRequest.mode:type_name -> fizzbuzz.v1.Mode
+	7, // 2: fizzbuzz.v1.GetMostRequestedResponse.stats:type_name -> fizzbuzz.v1.Stats
+	1, // 3: fizzbuzz.v1.Stats.rules:type_name -> fizzbuzz.v1.Rule
+	0, // 4: fizzbuzz.v1.Stats.mode:type_name -> fizzbuzz.v1.Mode
+	8, // 5: fizzbuzz.v1.Stats.last_hit_at:type_name -> google.protobuf.Timestamp
+	2, // 6: fizzbuzz.v1.FizzBuzzService.Run:input_type -> fizzbuzz.v1.RunRequest
+	2, // 7: fizzbuzz.v1.FizzBuzzService.RunStream:input_type -> fizzbuzz.v1.RunRequest
+	5, // 8: fizzbuzz.v1.FizzBuzzService.GetMostRequested:input_type -> fizzbuzz.v1.GetMostRequestedRequest
+	3, // 9: fizzbuzz.v1.FizzBuzzService.Run:output_type -> fizzbuzz.v1.RunResponse
+	4, // 10: fizzbuzz.v1.FizzBuzzService.RunStream:output_type -> fizzbuzz.v1.RunValue
+	6, // 11: fizzbuzz.v1.FizzBuzzService.GetMostRequested:output_type -> fizzbuzz.v1.GetMostRequestedResponse
+	9, // [9:12] is the sub-list for method output_type
+	6, // [6:9] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_fizzbuzz_proto_init() }
+func file_fizzbuzz_proto_init() {
+	if File_fizzbuzz_proto != nil {
+		return
+	}
+	file_fizzbuzz_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_fizzbuzz_proto_rawDesc), len(file_fizzbuzz_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_fizzbuzz_proto_goTypes,
+		DependencyIndexes: file_fizzbuzz_proto_depIdxs,
+		EnumInfos:         file_fizzbuzz_proto_enumTypes,
+		MessageInfos:      file_fizzbuzz_proto_msgTypes,
+	}.Build()
+	File_fizzbuzz_proto = out.File
+	file_fizzbuzz_proto_goTypes = nil
+	file_fizzbuzz_proto_depIdxs = nil
+}
@@ -0,0 +1,215 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: fizzbuzz.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	FizzBuzzService_Run_FullMethodName              = "/fizzbuzz.v1.FizzBuzzService/Run"
+	FizzBuzzService_RunStream_FullMethodName        = "/fizzbuzz.v1.FizzBuzzService/RunStream"
+	FizzBuzzService_GetMostRequested_FullMethodName = "/fizzbuzz.v1.FizzBuzzService/GetMostRequested"
+)
+
+// FizzBuzzServiceClient is the client API for FizzBuzzService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// FizzBuzzService generates FizzBuzz sequences and tracks usage statistics,
+// as the REST API does.
+type FizzBuzzServiceClient interface {
+	// Run returns the whole sequence, up to 100000 values: longer sequences
+	// must be streamed with RunStream.
+	Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*RunResponse, error)
+	// RunStream streams the sequence, one message per value.
+	RunStream(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RunValue], error)
+	// GetMostRequested returns the request having the most hits.
+	GetMostRequested(ctx context.Context, in *GetMostRequestedRequest, opts ...grpc.CallOption) (*GetMostRequestedResponse, error)
+}
+
+type fizzBuzzServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFizzBuzzServiceClient(cc grpc.ClientConnInterface) FizzBuzzServiceClient {
+	return &fizzBuzzServiceClient{cc}
+}
+
+func (c *fizzBuzzServiceClient) Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*RunResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RunResponse)
+	err := c.cc.Invoke(ctx, FizzBuzzService_Run_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fizzBuzzServiceClient) RunStream(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RunValue], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FizzBuzzService_ServiceDesc.Streams[0], FizzBuzzService_RunStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RunRequest, RunValue]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FizzBuzzService_RunStreamClient = grpc.ServerStreamingClient[RunValue]
+
+func (c *fizzBuzzServiceClient) GetMostRequested(ctx context.Context, in *GetMostRequestedRequest, opts ...grpc.CallOption) (*GetMostRequestedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetMostRequestedResponse)
+	err := c.cc.Invoke(ctx, FizzBuzzService_GetMostRequested_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FizzBuzzServiceServer is the server API for FizzBuzzService service.
+// All implementations must embed UnimplementedFizzBuzzServiceServer
+// for forward compatibility.
+//
+// FizzBuzzService generates FizzBuzz sequences and tracks usage statistics,
+// as the REST API does.
+type FizzBuzzServiceServer interface {
+	// Run returns the whole sequence, up to 100000 values: longer sequences
+	// must be streamed with RunStream.
+	Run(context.Context, *RunRequest) (*RunResponse, error)
+	// RunStream streams the sequence, one message per value.
+	RunStream(*RunRequest, grpc.ServerStreamingServer[RunValue]) error
+	// GetMostRequested returns the request having the most hits.
+	GetMostRequested(context.Context, *GetMostRequestedRequest) (*GetMostRequestedResponse, error)
+	mustEmbedUnimplementedFizzBuzzServiceServer()
+}
+
+// UnimplementedFizzBuzzServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFizzBuzzServiceServer struct{}
+
+func (UnimplementedFizzBuzzServiceServer) Run(context.Context, *RunRequest) (*RunResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Run not implemented")
+}
+func (UnimplementedFizzBuzzServiceServer) RunStream(*RunRequest, grpc.ServerStreamingServer[RunValue]) error {
+	return status.Errorf(codes.Unimplemented, "method RunStream not implemented")
+}
+func (UnimplementedFizzBuzzServiceServer) GetMostRequested(context.Context, *GetMostRequestedRequest) (*GetMostRequestedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMostRequested not implemented")
+}
+func (UnimplementedFizzBuzzServiceServer) mustEmbedUnimplementedFizzBuzzServiceServer() {}
+func (UnimplementedFizzBuzzServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeFizzBuzzServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FizzBuzzServiceServer will
+// result in compilation errors.
+type UnsafeFizzBuzzServiceServer interface {
+	mustEmbedUnimplementedFizzBuzzServiceServer()
+}
+
+func RegisterFizzBuzzServiceServer(s grpc.ServiceRegistrar, srv FizzBuzzServiceServer) {
+	// If the following call pancis, it indicates UnimplementedFizzBuzzServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FizzBuzzService_ServiceDesc, srv)
+}
+
+func _FizzBuzzService_Run_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FizzBuzzServiceServer).Run(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FizzBuzzService_Run_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FizzBuzzServiceServer).Run(ctx, req.(*RunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FizzBuzzService_RunStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RunRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FizzBuzzServiceServer).RunStream(m, &grpc.GenericServerStream[RunRequest, RunValue]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FizzBuzzService_RunStreamServer = grpc.ServerStreamingServer[RunValue]
+
+func _FizzBuzzService_GetMostRequested_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMostRequestedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FizzBuzzServiceServer).GetMostRequested(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FizzBuzzService_GetMostRequested_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FizzBuzzServiceServer).GetMostRequested(ctx, req.(*GetMostRequestedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FizzBuzzService_ServiceDesc is the grpc.ServiceDesc for FizzBuzzService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FizzBuzzService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "fizzbuzz.v1.FizzBuzzService",
+	HandlerType: (*FizzBuzzServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Run",
+			Handler:    _FizzBuzzService_Run_Handler,
+		},
+		{
+			MethodName: "GetMostRequested",
+			Handler:    _FizzBuzzService_GetMostRequested_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RunStream",
+			Handler:       _FizzBuzzService_RunStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "fizzbuzz.proto",
+}
@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"test-lbc/grpc/pb"
+	"test-lbc/http/handlers"
+	"test-lbc/pkg"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+type Server struct {
+	service handlers.FizzBuzzService
+	// keys are the API keys of the clients, nil to disable authentication
+	keys     pkg.KeyStore
+	bindAddr string
+	// drainTimeout is how long the in-flight calls are waited for on shutdown
+	drainTimeout time.Duration
+}
+
+func New(service handlers.FizzBuzzService, keys pkg.KeyStore, bindAddr string, drainTimeout time.Duration) *Server {
+	return &Server{
+		service:      service,
+		keys:         keys,
+		bindAddr:     bindAddr,
+		drainTimeout: drainTimeout,
+	}
+}
+
+// Run serves the gRPC API until ctx is done or the server fails. The server is
+// then stopped, waiting for its in-flight calls up to the drain timeout.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.bindAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", s.bindAddr, err)
+	}
+
+	// every call is traced, in the trace of the caller given by its traceparent metadata, if any
+	opts := []grpc.ServerOption{grpc.StatsHandler(otelgrpc.NewServerHandler())}
+	if s.keys != nil {
+		opts = append(opts, grpc.UnaryInterceptor(unaryAuth(s.keys)), grpc.StreamInterceptor(streamAuth(s.keys)))
+	}
+	server := grpc.NewServer(opts...)
+	pb.RegisterFizzBuzzServiceServer(server, &fizzBuzzServer{service: s.service})
+	reflection.Register(server)
+
+	slog.Info("start grpc server", "addr", s.bindAddr)
+	errc := make(chan error, 1)
+	go func() {
+		// Serve returns nil once stopped
+		if err := server.Serve(listener); err != nil {
+			errc <- fmt.Errorf("failed to serve on %s: %v", s.bindAddr, err)
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down grpc server")
+	case err = <-errc:
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(s.drainTimeout):
+		slog.Error("failed to drain grpc server: timeout", "addr", s.bindAddr)
+		server.Stop()
+	}
+
+	return err
+}
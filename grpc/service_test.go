@@ -0,0 +1,85 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"slices"
+	"test-lbc/grpc/pb"
+	"test-lbc/internal/testutil"
+	"test-lbc/pkg"
+	"test-lbc/pkg/store"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+)
+
+func TestServer_Run(t *testing.T) {
+	t.Run("Shutdown", func(t *testing.T) {
+		addr := testutil.FreeAddr(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		errc := make(chan error, 1)
+		go func() {
+			errc <- New(pkg.NewFizzBuzzService(store.NewMemory()), nil, addr, time.Second).Run(ctx)
+		}()
+
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer conn.Close()
+
+		// the call waits for the server to listen
+		callCtx, callCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer callCancel()
+		if _, err := pb.NewFizzBuzzServiceClient(conn).GetMostRequested(callCtx, &pb.GetMostRequestedRequest{}, grpc.WaitForReady(true)); err != nil {
+			t.Fatalf("expected the server to answer, got %v", err)
+		}
+
+		// reflection lists the service for the local tooling
+		reflectionStream, err := grpc_reflection_v1.NewServerReflectionClient(conn).ServerReflectionInfo(callCtx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := reflectionStream.Send(&grpc_reflection_v1.ServerReflectionRequest{
+			MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_ListServices{},
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		reflectionResp, err := reflectionStream.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.ContainsFunc(reflectionResp.GetListServicesResponse().GetService(), func(service *grpc_reflection_v1.ServiceResponse) bool {
+			return service.GetName() == pb.FizzBuzzService_ServiceDesc.ServiceName
+		}) {
+			t.Errorf("expected the service to be listed, got %v", reflectionResp)
+		}
+		reflectionStream.CloseSend()
+
+		cancel()
+		select {
+		case err := <-errc:
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected the server to stop")
+		}
+	})
+
+	t.Run("Listen error", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer listener.Close()
+
+		// the address is already in use
+		if err := New(pkg.NewFizzBuzzService(store.NewMemory()), nil, listener.Addr().String(), time.Second).Run(context.Background()); err == nil {
+			t.Errorf("expected an error, got nil")
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"test-lbc/http/handlers"
+	"test-lbc/http/models"
+	"test-lbc/pkg"
+	fModels "test-lbc/pkg/models"
+	"test-lbc/prometheus"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyRecordContextKey is the key of the record of the API key of the
+// client in the gin context, set along with APIKeyContextKey.
+const apiKeyRecordContextKey = "apiKeyRecord"
+
+// authenticate identifies the client by the API key of its Authorization
+// bearer token or X-API-Key header. A request without key goes on anonymous,
+// the routes requiring a scope rejecting it, while an unknown or revoked key
+// is rejected right away.
+// Every rejected key takes a token from the bucket of the client IP in
+// failures, if any: once it is empty, the keys of the client are rejected
+// without being looked up.
+func authenticate(keys pkg.KeyStore, failures *rateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); found {
+			key = strings.TrimSpace(token)
+		}
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		ip := c.ClientIP()
+		if failures != nil {
+			if retryAfter := failures.wait(ip); retryAfter > 0 {
+				prometheus.IncRateLimited(c.FullPath())
+				c.Header("Retry-After", strconv.Itoa(seconds(retryAfter)))
+				handlers.AbortWithProblem(c, models.NewProblem(http.StatusTooManyRequests, fmt.Sprintf("too many failed authentications, retry in %ds", seconds(retryAfter))))
+				return
+			}
+		}
+
+		record, err := keys.KeyByHash(pkg.HashAPIKey(key))
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "failed to authenticate", "error", err)
+			handlers.AbortWithProblem(c, models.NewProblem(http.StatusInternalServerError, "failed to check api key"))
+			return
+		}
+		if record == nil || record.RevokedAt != nil {
+			if failures != nil {
+				failures.take(ip)
+			}
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			handlers.AbortWithProblem(c, models.NewProblem(http.StatusUnauthorized, "invalid api key"))
+			return
+		}
+
+		c.Set(APIKeyContextKey, record.ID)
+		c.Set(apiKeyRecordContextKey, record)
+		c.Next()
+	}
+}
+
+// requireScope rejects the requests whose API key does not grant the scope.
+func requireScope(scope fModels.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get(apiKeyRecordContextKey)
+		record, _ := value.(*fModels.APIKey)
+		if record == nil {
+			c.Header("WWW-Authenticate", "Bearer")
+			handlers.AbortWithProblem(c, models.NewProblem(http.StatusUnauthorized, "an api key is required"))
+			return
+		}
+		if !record.HasScope(scope) {
+			handlers.AbortWithProblem(c, models.NewProblem(http.StatusForbidden, fmt.Sprintf("api key lacks the %q scope", scope)))
+			return
+		}
+
+		c.Next()
+	}
+}
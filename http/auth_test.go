@@ -0,0 +1,151 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"test-lbc/http/models"
+	"test-lbc/pkg"
+	fModels "test-lbc/pkg/models"
+	"test-lbc/pkg/store"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys, err := store.OpenFileKeys(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	newKey := func(scopes ...fModels.Scope) (string, string) {
+		key, record, err := pkg.NewAPIKey("test", scopes)
+		if err == nil {
+			err = keys.CreateKey(record)
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return key, record.ID
+	}
+	runKey, _ := newKey(fModels.ScopeRun)
+	readKey, _ := newKey(fModels.ScopeStatsRead)
+	adminKey, adminID := newKey(fModels.ScopeStatsAdmin)
+	revokedKey, revokedID := newKey(fModels.ScopeRun)
+	if err := keys.RevokeKey(revokedID, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server := New(store.NewMemory(), Config{Keys: keys})
+	server.router = gin.New()
+	server.router.Use(authenticate(keys, nil))
+	server.loadRoutes()
+
+	get := func(path string, header ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+	const (
+		runPath   = "/fizzbuzz/at/15?int1=3&int2=5&str1=fizz&str2=buzz"
+		statsPath = "/fizzbuzz/stats/most-requested"
+	)
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		header []string
+		code   int
+	}{
+		{"Probe without key", "/healthz", nil, http.StatusOK},
+		{"Without key", runPath, nil, http.StatusUnauthorized},
+		{"Bearer token", runPath, []string{"Authorization", "Bearer " + runKey}, http.StatusOK},
+		{"X-API-Key header", runPath, []string{"X-API-Key", runKey}, http.StatusOK},
+		{"Unknown key", runPath, []string{"X-API-Key", pkg.APIKeyPrefix + "unknown"}, http.StatusUnauthorized},
+		{"Revoked key", runPath, []string{"X-API-Key", revokedKey}, http.StatusUnauthorized},
+		{"Unknown key on a probe", "/healthz", []string{"X-API-Key", "unknown"}, http.StatusUnauthorized},
+		{"Missing scope", statsPath, []string{"X-API-Key", runKey}, http.StatusForbidden},
+		{"Admin scope implies read", statsPath, []string{"X-API-Key", adminKey}, http.StatusOK},
+		{"Missing run scope", runPath, []string{"X-API-Key", adminKey}, http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := get(tc.path, tc.header...)
+			if w.Code != tc.code {
+				t.Errorf("expected %d, got %d: %s", tc.code, w.Code, w.Body)
+			}
+			if w.Code >= http.StatusBadRequest && w.Header().Get("Content-Type") != models.ProblemContentType {
+				t.Errorf("expected a problem, got %s", w.Header().Get("Content-Type"))
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("expected a WWW-Authenticate header")
+			}
+		})
+	}
+
+	t.Run("Admin route", func(t *testing.T) {
+		for _, tc := range []struct {
+			key  string
+			code int
+		}{
+			{readKey, http.StatusForbidden},
+			{adminKey, http.StatusNoContent},
+		} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", "/fizzbuzz/stats", nil)
+			req.Header.Set("X-API-Key", tc.key)
+			server.router.ServeHTTP(w, req)
+			if w.Code != tc.code {
+				t.Errorf("expected %d, got %d: %s", tc.code, w.Code, w.Body)
+			}
+		}
+	})
+
+	t.Run("Failed authentications", func(t *testing.T) {
+		router := gin.New()
+		router.Use(authenticate(keys, newRateLimiter(RateLimit{Rate: 0.1, Burst: 2})))
+		router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+		get := func(key, remoteAddr string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set("X-API-Key", key)
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		for range 2 {
+			if w := get(pkg.APIKeyPrefix+"unknown", "192.0.2.1:1234"); w.Code != http.StatusUnauthorized {
+				t.Fatalf("expected 401, got %d", w.Code)
+			}
+		}
+		// the valid keys of the client are not looked up either
+		for _, key := range []string{pkg.APIKeyPrefix + "unknown", runKey} {
+			if w := get(key, "192.0.2.1:1234"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "10" {
+				t.Errorf("expected 429 retrying in 10s, got %d %v", w.Code, w.Header())
+			}
+		}
+		if w := get(runKey, "192.0.2.2:1234"); w.Code != http.StatusOK {
+			t.Errorf("expected another client to pass, got %d", w.Code)
+		}
+	})
+
+	t.Run("Key in context", func(t *testing.T) {
+		router := gin.New()
+		router.Use(authenticate(keys, nil))
+		router.GET("/", func(c *gin.Context) { c.String(http.StatusOK, c.GetString(APIKeyContextKey)) })
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", adminKey)
+		router.ServeHTTP(w, req)
+		if w.Body.String() != adminID {
+			t.Errorf("expected the key id %q in context, got %q", adminID, w.Body)
+		}
+	})
+}
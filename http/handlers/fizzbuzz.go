@@ -1,107 +1,294 @@
 package handlers
 
 import (
-	"database/sql"
-	"log"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"test-lbc/http/models"
 	"test-lbc/pkg"
 	fModels "test-lbc/pkg/models"
 	"test-lbc/prometheus"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type FizzBuzzService interface {
-	Run(params fModels.FizzBuzzParams) ([]string, error)
-	GetMostRequested() (*fModels.FizzBuzzStats, error)
+	Run(ctx context.Context, params fModels.FizzBuzzParams) (iter.Seq2[int, string], error)
+	RunBatch(ctx context.Context, params []fModels.FizzBuzzParams) ([]iter.Seq2[int, string], error)
+	Stream(ctx context.Context, params fModels.FizzBuzzParams, from int) (iter.Seq2[int, string], error)
+	GetMostRequested(ctx context.Context) (*fModels.FizzBuzzStats, error)
+	GetTop(ctx context.Context, n int, tieBreak fModels.TieBreak) ([]fModels.FizzBuzzRank, error)
+	GetMostRequestedSince(ctx context.Context, period time.Duration) (*fModels.FizzBuzzStats, error)
+	GetTimeSeries(ctx context.Context, params fModels.FizzBuzzParams, granularity fModels.Granularity, period time.Duration) ([]fModels.FizzBuzzBucket, error)
+	ResetStats(ctx context.Context) error
+	Ready(ctx context.Context) []fModels.HealthCheck
 }
 
-var serviceFactory = func(db *sql.DB) FizzBuzzService {
-	return pkg.NewFizzBuzzService(db)
+// maxTop is the maximum number of requests of the top stats.
+const maxTop = 100
+
+var serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
+	return pkg.NewFizzBuzzService(store)
 }
 
-func FizzBuzzRun(c *gin.Context, db *sql.DB) {
+func FizzBuzzRun(c *gin.Context, store pkg.StatsStore) {
 	prometheus.IncRequest("run")
-	params, errMes := getFizzBuzzParams(c)
-	if len(errMes) > 0 {
+	mediaType := c.NegotiateFormat(runMediaTypes...)
+	if mediaType == "" {
 		prometheus.IncStats("run", "error")
-		log.Println("failed to run fizzbuzz:")
-		for _, err := range errMes {
-			log.Println("\t", err)
+		slog.WarnContext(c.Request.Context(), "failed to run fizzbuzz: unsupported Accept", "accept", c.GetHeader("Accept"))
+		AbortWithProblem(c, models.NewProblem(http.StatusNotAcceptable, fmt.Sprintf("Accept must allow one of %v", runMediaTypes)))
+		return
+	}
+	format := runFormats[slices.Index(runMediaTypes, mediaType)]
+
+	params, violations := getFizzBuzzParams(c)
+	length := 0
+	if len(violations) == 0 {
+		length, _ = pkg.Len(params.Start, params.End, params.Step)
+		if length > format.maxLength {
+			violations = append(violations, models.NewViolation(models.CodeLimitOutOfRange, "limit", "%s can not hold more than %d values", format.mediaType, format.maxLength))
 		}
-		c.AbortWithStatusJSON(http.StatusBadRequest, models.ResponseError{
-			Errors: errMes,
-		})
+	}
+	if len(violations) > 0 {
+		prometheus.IncStats("run", "error")
+		slog.WarnContext(c.Request.Context(), "failed to run fizzbuzz", "violations", violations)
+		abortWithViolations(c, violations)
 		return
 	}
+	prometheus.ObserveRequestedValues("run", length)
 
-	result, err := serviceFactory(db).Run(*params)
+	result, err := serviceFactory(store).Run(c.Request.Context(), *params)
 	if err != nil {
-		log.Printf("failed to save stats: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to save stats", "error", err)
 		prometheus.IncStats("run", "error_on_stat_save")
 	} else {
 		prometheus.IncStats("run", "success")
 	}
 
-	c.JSON(http.StatusOK, result)
+	liftWriteDeadline(c)
+	c.Header("Content-Type", format.contentType)
+	c.Header("Vary", "Accept")
+	c.Status(http.StatusOK)
+	if err := format.write(c.Writer, result, length); err != nil {
+		slog.WarnContext(c.Request.Context(), "failed to write fizzbuzz result", "error", err)
+	}
 }
 
-func FizzBuzzStats(c *gin.Context, db *sql.DB) {
-	prometheus.IncRequest("stats")
-	mostRequested, err := serviceFactory(db).GetMostRequested()
-	if err != nil {
-		prometheus.IncStats("stats", "error")
-		log.Printf("failed to retrieve fizzbuzz stats: %v", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ResponseError{
-			Errors: []string{err.Error()},
-		})
+// FizzBuzzBatch runs the JSON array of requests of the body, answering the
+// result or the errors of every request, in the same order. A request that
+// fails to decode only fails itself.
+func FizzBuzzBatch(c *gin.Context, store pkg.StatsStore, maxBatchSize int) {
+	prometheus.IncRequest("batch")
+	var (
+		reqs       []json.RawMessage
+		violations []models.Violation
+	)
+	if err := decodeJSONBody(c, &reqs); err != nil {
+		violations = append(violations, bodyViolation(err))
+	} else if len(reqs) < 1 || len(reqs) > maxBatchSize {
+		violations = append(violations, models.NewViolation(models.CodeParamOutOfRange, "", "batch must hold between 1 and %d requests", maxBatchSize))
+	}
+	if len(violations) > 0 {
+		prometheus.IncStats("batch", "error")
+		slog.WarnContext(c.Request.Context(), "failed to run fizzbuzz batch", "violations", violations)
+		abortWithViolations(c, violations)
 		return
 	}
 
-	prometheus.IncStats("stats", "success")
-	c.JSON(http.StatusOK, mostRequested)
+	items := make([]models.ResponseBatchItem, len(reqs))
+	var params []fModels.FizzBuzzParams
+	for i, raw := range reqs {
+		var req fizzBuzzRequest
+		if err := decodeJSON(bytes.NewReader(raw), &req); err != nil {
+			items[i].Errors = []models.Violation{bodyViolation(err)}
+			continue
+		}
+		p, violations := req.validate()
+		if len(violations) > 0 {
+			items[i].Errors = violations
+			continue
+		}
+		length, _ := pkg.Len(p.Start, p.End, p.Step)
+		prometheus.ObserveRequestedValues("batch", length)
+		params = append(params, *p)
+	}
+
+	results, err := serviceFactory(store).RunBatch(c.Request.Context(), params)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to save stats", "error", err)
+		prometheus.IncStats("batch", "error_on_stat_save")
+	} else {
+		prometheus.IncStats("batch", "success")
+	}
+
+	liftWriteDeadline(c)
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := writeBatch(c.Writer, items, results); err != nil {
+		slog.WarnContext(c.Request.Context(), "failed to write fizzbuzz batch result", "error", err)
+	}
 }
 
-func getFizzBuzzParams(c *gin.Context) (*fModels.FizzBuzzParams, []string) {
+func FizzBuzzTop(c *gin.Context, store pkg.StatsStore) {
+	prometheus.IncRequest("top")
 	var (
-		int1Str  = c.Query("int1")
-		int2Str  = c.Query("int2")
-		limitStr = c.Query("limit")
-		str1     = c.Query("str1")
-		str2     = c.Query("str2")
+		nStr     = c.DefaultQuery("n", "10")
+		tieBreak = fModels.TieBreak(c.DefaultQuery("tiebreak", string(fModels.TieBreakRecent)))
 
-		errMes []string
+		violations []models.Violation
 	)
 
-	if int1Str == "" || int2Str == "" || limitStr == "" || str1 == "" || str2 == "" {
-		return nil, []string{"int1, int2, limit, str1 and str2 are all mandatory"}
+	n, err := strconv.Atoi(nStr)
+	if err != nil {
+		violations = append(violations, notIntegerViolation("n", nStr))
+	} else if n < 1 || n > maxTop {
+		violations = append(violations, models.NewViolation(models.CodeParamOutOfRange, "n", "n must be between 1 and %d", maxTop))
+	}
+	if !slices.Contains(fModels.TieBreaks, tieBreak) {
+		violations = append(violations, models.NewViolation(models.CodeParamInvalid, "tiebreak", "tiebreak must be one of %v", fModels.TieBreaks))
+	}
+	if len(violations) > 0 {
+		prometheus.IncStats("top", "error")
+		slog.WarnContext(c.Request.Context(), "failed to retrieve fizzbuzz top stats", "violations", violations)
+		abortWithViolations(c, violations)
+		return
+	}
+
+	top, err := serviceFactory(store).GetTop(c.Request.Context(), n, tieBreak)
+	if err != nil {
+		prometheus.IncStats("top", "error")
+		slog.ErrorContext(c.Request.Context(), "failed to retrieve fizzbuzz top stats", "error", err)
+		abortWithError(c, err)
+		return
+	}
+
+	prometheus.IncStats("top", "success")
+	c.JSON(http.StatusOK, top)
+}
+
+func FizzBuzzTimeSeries(c *gin.Context, store pkg.StatsStore) {
+	prometheus.IncRequest("timeseries")
+	params, violations := getFizzBuzzParams(c)
+
+	granularity := fModels.Granularity(c.DefaultQuery("granularity", string(fModels.GranularityHour)))
+	if !slices.Contains(fModels.Granularities, granularity) {
+		violations = append(violations, models.NewViolation(models.CodeParamInvalid, "granularity", "granularity must be one of %v", fModels.Granularities))
 	}
-	int1, err := strconv.Atoi(int1Str)
+	since, err := parsePeriod(c.DefaultQuery("since", "24h"))
 	if err != nil {
-		errMes = append(errMes, "int1 err: "+err.Error())
+		violations = append(violations, periodViolation("since", err))
+	} else if since/granularity.Duration() >= pkg.MaxTimeSeriesBuckets {
+		violations = append(violations, models.NewViolation(models.CodeParamOutOfRange, "since", "since must span less than %d buckets", pkg.MaxTimeSeriesBuckets))
 	}
 
-	int2, err := strconv.Atoi(int2Str)
+	if len(violations) > 0 {
+		prometheus.IncStats("timeseries", "error")
+		slog.WarnContext(c.Request.Context(), "failed to retrieve fizzbuzz time series", "violations", violations)
+		abortWithViolations(c, violations)
+		return
+	}
+
+	series, err := serviceFactory(store).GetTimeSeries(c.Request.Context(), *params, granularity, since)
 	if err != nil {
-		errMes = append(errMes, "int2 err: "+err.Error())
+		prometheus.IncStats("timeseries", "error")
+		slog.ErrorContext(c.Request.Context(), "failed to retrieve fizzbuzz time series", "error", err)
+		abortWithError(c, err)
+		return
 	}
 
-	limit, err := strconv.Atoi(limitStr)
+	prometheus.IncStats("timeseries", "success")
+	c.JSON(http.StatusOK, series)
+}
+
+func FizzBuzzAt(c *gin.Context) {
+	prometheus.IncRequest("at")
+	n, err := strconv.Atoi(c.Param("n"))
 	if err != nil {
-		errMes = append(errMes, "limit err: "+err.Error())
+		prometheus.IncStats("at", "error")
+		slog.WarnContext(c.Request.Context(), "failed to compute fizzbuzz value", "error", err)
+		abortWithViolations(c, []models.Violation{notIntegerViolation("n", c.Param("n"))})
+		return
+	}
+
+	rules, mode, violations := getRulesQuery(c)
+	if len(violations) > 0 {
+		prometheus.IncStats("at", "error")
+		slog.WarnContext(c.Request.Context(), "failed to compute fizzbuzz value", "violations", violations)
+		abortWithViolations(c, violations)
+		return
 	}
 
-	if limit <= 0 {
-		errMes = append(errMes, "limit must be greater than 0")
+	prometheus.IncStats("at", "success")
+	c.JSON(http.StatusOK, pkg.At(rules, mode, n))
+}
+
+func FizzBuzzCount(c *gin.Context) {
+	prometheus.IncRequest("count")
+	params, violations := getFizzBuzzParams(c)
+	if params != nil && params.Step != 1 && params.Step != -1 {
+		violations = append(violations, models.NewViolation(models.CodeParamInvalid, "step", "step must be 1 or -1 to count"))
+	}
+	if len(violations) > 0 {
+		prometheus.IncStats("count", "error")
+		slog.WarnContext(c.Request.Context(), "failed to count fizzbuzz values", "violations", violations)
+		abortWithViolations(c, violations)
+		return
+	}
+
+	prometheus.IncStats("count", "success")
+	c.JSON(http.StatusOK, pkg.Count(params.Rules, params.Mode, params.Start, params.End))
+}
+
+func FizzBuzzStats(c *gin.Context, store pkg.StatsStore) {
+	prometheus.IncRequest("stats")
+
+	var (
+		mostRequested *fModels.FizzBuzzStats
+		err           error
+	)
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		var since time.Duration
+		since, err = parsePeriod(sinceStr)
+		if err != nil {
+			prometheus.IncStats("stats", "error")
+			slog.WarnContext(c.Request.Context(), "failed to retrieve fizzbuzz stats", "error", err)
+			abortWithViolations(c, []models.Violation{periodViolation("since", err)})
+			return
+		}
+		mostRequested, err = serviceFactory(store).GetMostRequestedSince(c.Request.Context(), since)
+	} else {
+		mostRequested, err = serviceFactory(store).GetMostRequested(c.Request.Context())
+	}
+	if err != nil {
+		prometheus.IncStats("stats", "error")
+		slog.ErrorContext(c.Request.Context(), "failed to retrieve fizzbuzz stats", "error", err)
+		abortWithError(c, err)
+		return
+	}
+
+	prometheus.IncStats("stats", "success")
+	c.JSON(http.StatusOK, models.NewResponseMostRequested(mostRequested))
+}
+
+// FizzBuzzResetStats deletes the stats of every request.
+func FizzBuzzResetStats(c *gin.Context, store pkg.StatsStore) {
+	prometheus.IncRequest("reset")
+	if err := serviceFactory(store).ResetStats(c.Request.Context()); err != nil {
+		prometheus.IncStats("reset", "error")
+		slog.ErrorContext(c.Request.Context(), "failed to reset fizzbuzz stats", "error", err)
+		abortWithError(c, err)
+		return
 	}
 
-	return &fModels.FizzBuzzParams{
-		Int1:  int1,
-		Int2:  int2,
-		Limit: limit,
-		Str1:  str1,
-		Str2:  str2,
-	}, errMes
+	prometheus.IncStats("reset", "success")
+	slog.InfoContext(c.Request.Context(), "reset fizzbuzz stats")
+	c.Status(http.StatusNoContent)
 }
@@ -1,13 +1,20 @@
 package handlers
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"errors"
+	"iter"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"test-lbc/http/models"
+	"test-lbc/pkg"
 	fModels "test-lbc/pkg/models"
 
 	"github.com/gin-gonic/gin"
@@ -15,24 +22,81 @@ import (
 
 // MockService implements FizzBuzzService for testing purposes
 type MockService struct {
-	RunFunc              func(params fModels.FizzBuzzParams) ([]string, error)
+	RunFunc              func(params fModels.FizzBuzzParams) (iter.Seq2[int, string], error)
+	RunBatchFunc         func(params []fModels.FizzBuzzParams) ([]iter.Seq2[int, string], error)
+	StreamFunc           func(params fModels.FizzBuzzParams, from int) (iter.Seq2[int, string], error)
 	GetMostRequestedFunc func() (*fModels.FizzBuzzStats, error)
+	GetTopFunc           func(n int, tieBreak fModels.TieBreak) ([]fModels.FizzBuzzRank, error)
+
+	GetMostRequestedSinceFunc func(period time.Duration) (*fModels.FizzBuzzStats, error)
+	GetTimeSeriesFunc         func(params fModels.FizzBuzzParams, granularity fModels.Granularity, period time.Duration) ([]fModels.FizzBuzzBucket, error)
+	ResetStatsFunc            func() error
+	ReadyFunc                 func(ctx context.Context) []fModels.HealthCheck
 }
 
-func (m *MockService) Run(params fModels.FizzBuzzParams) ([]string, error) {
+func (m *MockService) Run(ctx context.Context, params fModels.FizzBuzzParams) (iter.Seq2[int, string], error) {
 	if m.RunFunc != nil {
 		return m.RunFunc(params)
 	}
 	return nil, nil
 }
 
-func (m *MockService) GetMostRequested() (*fModels.FizzBuzzStats, error) {
+func (m *MockService) RunBatch(ctx context.Context, params []fModels.FizzBuzzParams) ([]iter.Seq2[int, string], error) {
+	if m.RunBatchFunc != nil {
+		return m.RunBatchFunc(params)
+	}
+	return nil, nil
+}
+
+func (m *MockService) Stream(ctx context.Context, params fModels.FizzBuzzParams, from int) (iter.Seq2[int, string], error) {
+	if m.StreamFunc != nil {
+		return m.StreamFunc(params, from)
+	}
+	return nil, nil
+}
+
+func (m *MockService) GetMostRequested(ctx context.Context) (*fModels.FizzBuzzStats, error) {
 	if m.GetMostRequestedFunc != nil {
 		return m.GetMostRequestedFunc()
 	}
 	return nil, nil
 }
 
+func (m *MockService) GetTop(ctx context.Context, n int, tieBreak fModels.TieBreak) ([]fModels.FizzBuzzRank, error) {
+	if m.GetTopFunc != nil {
+		return m.GetTopFunc(n, tieBreak)
+	}
+	return nil, nil
+}
+
+func (m *MockService) GetMostRequestedSince(ctx context.Context, period time.Duration) (*fModels.FizzBuzzStats, error) {
+	if m.GetMostRequestedSinceFunc != nil {
+		return m.GetMostRequestedSinceFunc(period)
+	}
+	return nil, nil
+}
+
+func (m *MockService) GetTimeSeries(ctx context.Context, params fModels.FizzBuzzParams, granularity fModels.Granularity, period time.Duration) ([]fModels.FizzBuzzBucket, error) {
+	if m.GetTimeSeriesFunc != nil {
+		return m.GetTimeSeriesFunc(params, granularity, period)
+	}
+	return nil, nil
+}
+
+func (m *MockService) ResetStats(ctx context.Context) error {
+	if m.ResetStatsFunc != nil {
+		return m.ResetStatsFunc()
+	}
+	return nil
+}
+
+func (m *MockService) Ready(ctx context.Context) []fModels.HealthCheck {
+	if m.ReadyFunc != nil {
+		return m.ReadyFunc(ctx)
+	}
+	return nil
+}
+
 func TestFizzBuzzRun(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -63,16 +127,27 @@ func TestFizzBuzzRun(t *testing.T) {
 		if w.Code != http.StatusBadRequest {
 			t.Errorf("Expected status 400, got %d", w.Code)
 		}
+		if contentType := w.Header().Get("Content-Type"); contentType != models.ProblemContentType {
+			t.Errorf("Expected a problem, got %s", contentType)
+		}
+		var problem models.Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if problem.Type != "urn:fizzbuzz:problem:validation-error" || problem.Status != http.StatusBadRequest || problem.Title == "" ||
+			len(problem.Errors) != 1 || problem.Errors[0].Code != models.CodeParamNotInteger || problem.Errors[0].Field != "int1" {
+			t.Errorf("Expected a param_not_integer violation of int1, got %+v", problem)
+		}
 	})
 
 	t.Run("Success", func(t *testing.T) {
 		expectedResp := []string{"1", "2", "fizz"}
 		mockSvc := &MockService{
-			RunFunc: func(params fModels.FizzBuzzParams) ([]string, error) {
-				return expectedResp, nil
+			RunFunc: func(params fModels.FizzBuzzParams) (iter.Seq2[int, string], error) {
+				return slices.All(expectedResp), nil
 			},
 		}
-		serviceFactory = func(db *sql.DB) FizzBuzzService {
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
 			return mockSvc
 		}
 
@@ -90,8 +165,413 @@ func TestFizzBuzzRun(t *testing.T) {
 		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
 			t.Fatalf("Failed to unmarshal response: %v", err)
 		}
+		if !reflect.DeepEqual(resp, expectedResp) {
+			t.Errorf("Expected %v, got %v", expectedResp, resp)
+		}
+	})
+
+	t.Run("Accept", func(t *testing.T) {
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
+			return &MockService{
+				RunFunc: func(params fModels.FizzBuzzParams) (iter.Seq2[int, string], error) {
+					return slices.All([]string{"1", "2", "fizz"}), nil
+				},
+			}
+		}
+
+		testCases := []struct {
+			accept              string
+			expectedContentType string
+			expectedBody        string
+		}{
+			{accept: "", expectedContentType: "application/json; charset=utf-8", expectedBody: `["1","2","fizz"]`},
+			{accept: "*/*", expectedContentType: "application/json; charset=utf-8", expectedBody: `["1","2","fizz"]`},
+			{accept: "text/csv, text/plain", expectedContentType: "text/csv; charset=utf-8", expectedBody: "index,value\n0,1\n1,2\n2,fizz\n"},
+			{accept: "text/*", expectedContentType: "text/plain; charset=utf-8", expectedBody: "1\n2\nfizz\n"},
+			{accept: "application/x-ndjson", expectedContentType: "application/x-ndjson", expectedBody: "\"1\"\n\"2\"\n\"fizz\"\n"},
+			{accept: "application/x-msgpack", expectedContentType: "application/x-msgpack", expectedBody: "\x93\xa11\xa12\xa4fizz"},
+		}
+
+		for _, tc := range testCases {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("POST", "/fizzbuzz/run?int1=3&int2=5&limit=3&str1=fizz&str2=buzz", nil)
+			c.Request.Header.Set("Accept", tc.accept)
+
+			FizzBuzzRun(c, nil)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("%s: Expected status 200, got %d", tc.accept, w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != tc.expectedContentType {
+				t.Errorf("%s: Expected content type %s, got %s", tc.accept, tc.expectedContentType, contentType)
+			}
+			if w.Body.String() != tc.expectedBody {
+				t.Errorf("%s: Expected body %q, got %q", tc.accept, tc.expectedBody, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("Not acceptable", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/fizzbuzz/run?int1=3&int2=5&limit=3&str1=fizz&str2=buzz", nil)
+		c.Request.Header.Set("Accept", "application/xml")
+
+		FizzBuzzRun(c, nil)
+
+		if w.Code != http.StatusNotAcceptable {
+			t.Errorf("Expected status 406, got %d", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "text/csv") {
+			t.Errorf("Expected the supported types, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Too long for MessagePack", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/fizzbuzz/run?int1=3&int2=5&limit=5000000000&str1=fizz&str2=buzz", nil)
+		c.Request.Header.Set("Accept", "application/msgpack")
+
+		FizzBuzzRun(c, nil)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("Classic and rule Parameters", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/fizzbuzz/run?int1=3&int2=5&limit=3&str1=fizz&str2=buzz&rule=7:bazz", nil)
+
+		FizzBuzzRun(c, nil)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("Invalid mode", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/fizzbuzz/run?int1=3&int2=5&limit=3&str1=fizz&str2=buzz&mode=lcm", nil)
+
+		FizzBuzzRun(c, nil)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("Invalid rule", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/fizzbuzz/run?limit=3&rule=fizz", nil)
+
+		FizzBuzzRun(c, nil)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestFizzBuzzBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	origFactory := serviceFactory
+	defer func() { serviceFactory = origFactory }()
+
+	t.Run("Success", func(t *testing.T) {
+		var saved []fModels.FizzBuzzParams
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
+			return &MockService{
+				RunBatchFunc: func(params []fModels.FizzBuzzParams) ([]iter.Seq2[int, string], error) {
+					saved = params
+					results := make([]iter.Seq2[int, string], len(params))
+					for i, p := range params {
+						results[i] = pkg.Sequence(p)
+					}
+					return results, errors.New("db error")
+				},
+			}
+		}
+
+		body := `[
+			{"int1":3,"int2":5,"limit":3,"str1":"fizz","str2":"buzz"},
+			{"limit":3,"mode":"lcm","rules":[{"divisor":3,"word":"fizz"}]},
+			{"start":5,"end":4,"step":-1,"rules":[{"divisor":2,"word":"fizz"}]}
+		]`
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/fizzbuzz/batch", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		FizzBuzzBatch(c, nil, 3)
+
+		// stats errors do not fail the batch
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(saved) != 2 {
+			t.Errorf("Expected the 2 valid requests to be run, got %v", saved)
+		}
+
+		var resp []models.ResponseBatchItem
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
 		if len(resp) != 3 {
-			t.Errorf("Expected 3 items, got %d", len(resp))
+			t.Fatalf("Expected 3 items, got %v", resp)
+		}
+		if !reflect.DeepEqual(resp[0].Result, []string{"1", "2", "fizz"}) || len(resp[0].Errors) > 0 {
+			t.Errorf("Expected the first result, got %v", resp[0])
+		}
+		if len(resp[1].Errors) == 0 || resp[1].Result != nil {
+			t.Errorf("Expected errors for the second request, got %v", resp[1])
+		}
+		if !reflect.DeepEqual(resp[2].Result, []string{"5", "fizz"}) || len(resp[2].Errors) > 0 {
+			t.Errorf("Expected the third result, got %v", resp[2])
+		}
+	})
+
+	t.Run("Undecodable request", func(t *testing.T) {
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
+			return &MockService{
+				RunBatchFunc: func(params []fModels.FizzBuzzParams) ([]iter.Seq2[int, string], error) {
+					results := make([]iter.Seq2[int, string], len(params))
+					for i, p := range params {
+						results[i] = pkg.Sequence(p)
+					}
+					return results, nil
+				},
+			}
+		}
+
+		body := `[{"limit":3,"int3":7},{"limit":"3"},{"int1":3,"int2":5,"limit":3,"str1":"fizz","str2":"buzz"}]`
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/fizzbuzz/batch", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		FizzBuzzBatch(c, nil, 3)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp []models.ResponseBatchItem
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp) != 3 {
+			t.Fatalf("Expected 3 items, got %s, %v", w.Body.String(), err)
+		}
+		for i, field := range []string{"", "limit"} {
+			if len(resp[i].Errors) != 1 || resp[i].Errors[0].Code != models.CodeBodyInvalid || resp[i].Errors[0].Field != field {
+				t.Errorf("Expected a body_invalid error of %q for request %d, got %v", field, i, resp[i])
+			}
+		}
+		if !reflect.DeepEqual(resp[2].Result, []string{"1", "2", "fizz"}) {
+			t.Errorf("Expected the third result, got %v", resp[2])
+		}
+	})
+
+	testCases := []struct {
+		name string
+		body string
+	}{
+		{name: "Empty batch", body: `[]`},
+		{name: "Too many requests", body: `[{"limit":1},{"limit":2},{"limit":3},{"limit":4}]`},
+		{name: "Not an array", body: `{"limit":3}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("POST", "/fizzbuzz/batch", strings.NewReader(tc.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			FizzBuzzBatch(c, nil, 3)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestFizzBuzzTop(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	origFactory := serviceFactory
+	defer func() { serviceFactory = origFactory }()
+
+	t.Run("Success", func(t *testing.T) {
+		mockSvc := &MockService{
+			GetTopFunc: func(n int, tieBreak fModels.TieBreak) ([]fModels.FizzBuzzRank, error) {
+				if n != 3 || tieBreak != fModels.TieBreakLexical {
+					t.Errorf("Unexpected n %d and tie-break %s", n, tieBreak)
+				}
+				return []fModels.FizzBuzzRank{{Rank: 1}, {Rank: 2, Tied: true}, {Rank: 2, Tied: true}}, nil
+			},
+		}
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
+			return mockSvc
+		}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/fizzbuzz/stats/top?n=3&tiebreak=lexical", nil)
+
+		FizzBuzzTop(c, nil)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		var resp []fModels.FizzBuzzRank
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(resp) != 3 || resp[2].Rank != 2 {
+			t.Errorf("Unexpected response %v", resp)
+		}
+	})
+
+	t.Run("Invalid Parameters", func(t *testing.T) {
+		for _, query := range []string{"n=0", "n=abc", "n=1000", "tiebreak=random"} {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("GET", "/fizzbuzz/stats/top?"+query, nil)
+
+			FizzBuzzTop(c, nil)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: Expected status 400, got %d", query, w.Code)
+			}
+		}
+	})
+
+	t.Run("Service Error", func(t *testing.T) {
+		mockSvc := &MockService{
+			GetTopFunc: func(n int, tieBreak fModels.TieBreak) ([]fModels.FizzBuzzRank, error) {
+				return nil, errors.New("database error")
+			},
+		}
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
+			return mockSvc
+		}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/fizzbuzz/stats/top", nil)
+
+		FizzBuzzTop(c, nil)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500, got %d", w.Code)
+		}
+		var problem models.Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil || problem.Type != "urn:fizzbuzz:problem:internal-error" || problem.Detail != "database error" {
+			t.Errorf("Expected an internal error problem, got %+v, %v", problem, err)
+		}
+	})
+}
+
+func TestFizzBuzzAt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "n", Value: "987654321"}}
+		c.Request, _ = http.NewRequest("GET", "/fizzbuzz/at/987654321?rule=3:Fizz&rule=5:Buzz", nil)
+
+		FizzBuzzAt(c)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+
+		var resp fModels.FizzBuzzValue
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if resp.Value != "Fizz" || len(resp.Matched) != 1 {
+			t.Errorf("Expected Fizz matching one rule, got %v", resp)
+		}
+	})
+
+	t.Run("Range params ignored", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "n", Value: "15"}}
+		c.Request, _ = http.NewRequest("GET", "/fizzbuzz/at/15?rule=3:Fizz&rule=5:Buzz&limit=abc&step=0", nil)
+
+		FizzBuzzAt(c)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Invalid n", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "n", Value: "99999999999999999999"}}
+		c.Request, _ = http.NewRequest("GET", "/fizzbuzz/at/99999999999999999999?rule=3:Fizz", nil)
+
+		FizzBuzzAt(c)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("Missing rules", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "n", Value: "3"}}
+		c.Request, _ = http.NewRequest("GET", "/fizzbuzz/at/3", nil)
+
+		FizzBuzzAt(c)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestFizzBuzzCount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/fizzbuzz/count?int1=3&int2=5&start=1&end=1000000000000000000&str1=fizz&str2=buzz", nil)
+
+		FizzBuzzCount(c)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+
+		var resp fModels.FizzBuzzCount
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if resp.Total != 1e18 || resp.Words["fizzbuzz"] != 66666666666666666 {
+			t.Errorf("Unexpected count %v", resp)
+		}
+	})
+
+	t.Run("Invalid step", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/fizzbuzz/count?int1=3&int2=5&start=1&end=100&step=2&str1=fizz&str2=buzz", nil)
+
+		FizzBuzzCount(c)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
 		}
 	})
 }
@@ -105,10 +585,10 @@ func TestFizzBuzzStats(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mockSvc := &MockService{
 			GetMostRequestedFunc: func() (*fModels.FizzBuzzStats, error) {
-				return &fModels.FizzBuzzStats{Int1: 3, Int2: 5, Limit: 100, Str1: "f", Str2: "b", Hits: 10}, nil
+				return &fModels.FizzBuzzStats{Start: 1, End: 100, Step: 1, Rules: fModels.ClassicRules(3, 5, "f", "b"), Mode: fModels.ModeConcat, Hits: 10}, nil
 			},
 		}
-		serviceFactory = func(db *sql.DB) FizzBuzzService {
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
 			return mockSvc
 		}
 
@@ -121,6 +601,79 @@ func TestFizzBuzzStats(t *testing.T) {
 		if w.Code != http.StatusOK {
 			t.Errorf("Expected status 200, got %d", w.Code)
 		}
+
+		var resp map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		for field, expected := range map[string]any{"int1": 3.0, "int2": 5.0, "limit": 100.0, "str1": "f", "str2": "b", "end": 100.0, "hits": 10.0} {
+			if resp[field] != expected {
+				t.Errorf("Expected %s %v, got %v", field, expected, resp[field])
+			}
+		}
+	})
+
+	t.Run("Not classic", func(t *testing.T) {
+		mockSvc := &MockService{
+			GetMostRequestedFunc: func() (*fModels.FizzBuzzStats, error) {
+				return &fModels.FizzBuzzStats{Start: 1, End: 100, Step: 1, Rules: fModels.ClassicRules(3, 5, "f", "b"), Mode: fModels.ModeFirst, Hits: 10}, nil
+			},
+		}
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
+			return mockSvc
+		}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/fizzbuzz/stats/most-requested", nil)
+
+		FizzBuzzStats(c, nil)
+
+		var resp map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if _, ok := resp["int1"]; ok || resp["mode"] != "first" {
+			t.Errorf("Expected no classic fields, got %v", resp)
+		}
+	})
+
+	t.Run("Since", func(t *testing.T) {
+		mockSvc := &MockService{
+			GetMostRequestedSinceFunc: func(period time.Duration) (*fModels.FizzBuzzStats, error) {
+				if period != 7*24*time.Hour {
+					t.Errorf("Unexpected period %s", period)
+				}
+				return &fModels.FizzBuzzStats{Start: 1, End: 100, Step: 1, Rules: fModels.ClassicRules(3, 5, "f", "b"), Hits: 4}, nil
+			},
+		}
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
+			return mockSvc
+		}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/fizzbuzz/stats/most-requested?since=7d", nil)
+
+		FizzBuzzStats(c, nil)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("Invalid Since", func(t *testing.T) {
+		for _, since := range []string{"abc", "-1h", "0d", "213504d"} {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("GET", "/fizzbuzz/stats/most-requested?since="+since, nil)
+
+			FizzBuzzStats(c, nil)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: Expected status 400, got %d", since, w.Code)
+			}
+		}
 	})
 
 	t.Run("Service Error", func(t *testing.T) {
@@ -129,7 +682,7 @@ func TestFizzBuzzStats(t *testing.T) {
 				return nil, errors.New("database error")
 			},
 		}
-		serviceFactory = func(db *sql.DB) FizzBuzzService {
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
 			return mockSvc
 		}
 
@@ -144,3 +697,125 @@ func TestFizzBuzzStats(t *testing.T) {
 		}
 	})
 }
+
+func TestFizzBuzzTimeSeries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	origFactory := serviceFactory
+	defer func() { serviceFactory = origFactory }()
+
+	t.Run("Success", func(t *testing.T) {
+		mockSvc := &MockService{
+			GetTimeSeriesFunc: func(params fModels.FizzBuzzParams, granularity fModels.Granularity, period time.Duration) ([]fModels.FizzBuzzBucket, error) {
+				if params.End != 100 || granularity != fModels.GranularityMinute || period != 90*time.Minute {
+					t.Errorf("Unexpected params %v, granularity %s and period %s", params, granularity, period)
+				}
+				return []fModels.FizzBuzzBucket{{Hits: 0}, {Hits: 2}}, nil
+			},
+		}
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
+			return mockSvc
+		}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/fizzbuzz/stats/timeseries?int1=3&int2=5&limit=100&str1=f&str2=b&granularity=minute&since=90m", nil)
+
+		FizzBuzzTimeSeries(c, nil)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		var resp []fModels.FizzBuzzBucket
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(resp) != 2 || resp[1].Hits != 2 {
+			t.Errorf("Unexpected response %v", resp)
+		}
+	})
+
+	t.Run("Invalid Parameters", func(t *testing.T) {
+		for _, query := range []string{"granularity=week", "since=abc", "since=30d&granularity=minute", "mode=random"} {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("GET", "/fizzbuzz/stats/timeseries?int1=3&int2=5&limit=100&str1=f&str2=b&"+query, nil)
+
+			FizzBuzzTimeSeries(c, nil)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: Expected status 400, got %d", query, w.Code)
+			}
+		}
+	})
+
+	t.Run("Service Error", func(t *testing.T) {
+		mockSvc := &MockService{
+			GetTimeSeriesFunc: func(params fModels.FizzBuzzParams, granularity fModels.Granularity, period time.Duration) ([]fModels.FizzBuzzBucket, error) {
+				return nil, errors.New("database error")
+			},
+		}
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
+			return mockSvc
+		}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/fizzbuzz/stats/timeseries?int1=3&int2=5&limit=100&str1=f&str2=b", nil)
+
+		FizzBuzzTimeSeries(c, nil)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500, got %d", w.Code)
+		}
+	})
+}
+
+func TestFizzBuzzResetStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	origFactory := serviceFactory
+	defer func() { serviceFactory = origFactory }()
+
+	t.Run("Success", func(t *testing.T) {
+		reset := false
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
+			return &MockService{
+				ResetStatsFunc: func() error {
+					reset = true
+					return nil
+				},
+			}
+		}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("DELETE", "/fizzbuzz/stats", nil)
+
+		FizzBuzzResetStats(c, nil)
+		c.Writer.WriteHeaderNow()
+
+		if w.Code != http.StatusNoContent || !reset {
+			t.Errorf("Expected the stats reset with status 204, got %d, %t", w.Code, reset)
+		}
+	})
+
+	t.Run("Service Error", func(t *testing.T) {
+		serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
+			return &MockService{
+				ResetStatsFunc: func() error {
+					return errors.New("database error")
+				},
+			}
+		}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("DELETE", "/fizzbuzz/stats", nil)
+
+		FizzBuzzResetStats(c, nil)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500, got %d", w.Code)
+		}
+	})
+}
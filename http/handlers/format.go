@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"iter"
+	"math"
+	"strconv"
+	"test-lbc/http/models"
+)
+
+// runFormat is a media type the sequence of a run can be written in.
+type runFormat struct {
+	mediaType   string
+	contentType string
+	// maxLength is the maximum length of a sequence the format can hold
+	maxLength int
+	write     func(w io.Writer, seq iter.Seq2[int, string], length int) error
+}
+
+// runFormats are the formats of a run, the first one being the default.
+var runFormats = []runFormat{
+	{mediaType: "application/json", contentType: "application/json; charset=utf-8", maxLength: math.MaxInt, write: writeJSONArray},
+	{mediaType: "application/x-ndjson", contentType: "application/x-ndjson", maxLength: math.MaxInt, write: writeNDJSON},
+	{mediaType: "text/plain", contentType: "text/plain; charset=utf-8", maxLength: math.MaxInt, write: writeLines},
+	{mediaType: "text/csv", contentType: "text/csv; charset=utf-8", maxLength: math.MaxInt, write: writeCSV},
+	{mediaType: "application/msgpack", contentType: "application/msgpack", maxLength: math.MaxUint32, write: writeMsgpackArray},
+	{mediaType: "application/x-msgpack", contentType: "application/x-msgpack", maxLength: math.MaxUint32, write: writeMsgpackArray},
+}
+
+// runMediaTypes are the media types of runFormats, in the same order.
+var runMediaTypes = func() []string {
+	var mediaTypes []string
+	for _, format := range runFormats {
+		mediaTypes = append(mediaTypes, format.mediaType)
+	}
+	return mediaTypes
+}()
+
+// writeJSONArray writes seq as a JSON array, one value at a time.
+func writeJSONArray(w io.Writer, seq iter.Seq2[int, string], _ int) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for i, value := range seq {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(encoded); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]")
+
+	return err
+}
+
+// writeNDJSON writes every value of seq as a JSON string on its own line.
+func writeNDJSON(w io.Writer, seq iter.Seq2[int, string], _ int) error {
+	encoder := json.NewEncoder(w)
+	for _, value := range seq {
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeLines writes every value of seq on its own line.
+func writeLines(w io.Writer, seq iter.Seq2[int, string], _ int) error {
+	for _, value := range seq {
+		if _, err := io.WriteString(w, value+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeCSV writes seq as index,value records, after a header.
+func writeCSV(w io.Writer, seq iter.Seq2[int, string], _ int) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"index", "value"}); err != nil {
+		return err
+	}
+	for i, value := range seq {
+		if err := writer.Write([]string{strconv.Itoa(i), value}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// writeMsgpackArray writes seq as a MessagePack array of length strings.
+func writeMsgpackArray(w io.Writer, seq iter.Seq2[int, string], length int) error {
+	if _, err := w.Write(msgpackHeader(nil, 0x90, 0xdc, length)); err != nil {
+		return err
+	}
+	var buf []byte
+	for _, value := range seq {
+		buf = append(msgpackStrHeader(buf[:0], len(value)), value...)
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// msgpackStrHeader appends the header of a string of n bytes to buf.
+func msgpackStrHeader(buf []byte, n int) []byte {
+	if n < 32 {
+		return append(buf, 0xa0|byte(n))
+	}
+	if n <= math.MaxUint8 {
+		return append(buf, 0xd9, byte(n))
+	}
+
+	return msgpackHeader(buf, 0, 0xda, n)
+}
+
+// msgpackHeader appends the header of an array or string of n items to buf:
+// fix is the fixed size marker (0 when there is none) and the 16 bits marker
+// is followed by the 32 bits one.
+func msgpackHeader(buf []byte, fix, marker16 byte, n int) []byte {
+	switch {
+	case fix != 0 && n < 16:
+		return append(buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, marker16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, marker16+1), uint32(n))
+	}
+}
+
+// writeBatch writes the items of a batch as a JSON array: an item having
+// errors is written as is, the other ones with the next of the results,
+// one value at a time.
+func writeBatch(w io.Writer, items []models.ResponseBatchItem, results []iter.Seq2[int, string]) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for i, item := range items {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if len(item.Errors) > 0 {
+			encoded, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(encoded); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := io.WriteString(w, `{"result":`); err != nil {
+			return err
+		}
+		if err := writeJSONArray(w, results[0], 0); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "}"); err != nil {
+			return err
+		}
+		results = results[1:]
+	}
+	_, err := io.WriteString(w, "]")
+
+	return err
+}
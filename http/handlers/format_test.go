@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRunFormats(t *testing.T) {
+	longWord := strings.Repeat("z", 40)
+	values := []string{"1", "fi\"zz", "bu,zz", longWord}
+
+	testCases := []struct {
+		mediaType string
+		values    []string
+		expected  string
+	}{
+		{mediaType: "application/json", values: nil, expected: `[]`},
+		{mediaType: "application/json", values: values, expected: `["1","fi\"zz","bu,zz","` + longWord + `"]`},
+		{mediaType: "application/x-ndjson", values: values, expected: "\"1\"\n\"fi\\\"zz\"\n\"bu,zz\"\n\"" + longWord + "\"\n"},
+		{mediaType: "text/plain", values: values, expected: "1\nfi\"zz\nbu,zz\n" + longWord + "\n"},
+		{mediaType: "text/csv", values: values, expected: "index,value\n0,1\n1,\"fi\"\"zz\"\n2,\"bu,zz\"\n3," + longWord + "\n"},
+		{mediaType: "text/csv", values: nil, expected: "index,value\n"},
+		{mediaType: "application/msgpack", values: values, expected: "\x94\xa11\xa5fi\"zz\xa5bu,zz\xd9\x28" + longWord},
+		{mediaType: "application/msgpack", values: nil, expected: "\x90"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.mediaType, func(t *testing.T) {
+			format := runFormats[slices.Index(runMediaTypes, tc.mediaType)]
+
+			var buf strings.Builder
+			if err := format.write(&buf, slices.All(tc.values), len(tc.values)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if buf.String() != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestMsgpackHeaders(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   []byte
+		expected []byte
+	}{
+		{name: "fixarray", header: msgpackHeader(nil, 0x90, 0xdc, 15), expected: []byte{0x9f}},
+		{name: "array16", header: msgpackHeader(nil, 0x90, 0xdc, 16), expected: []byte{0xdc, 0x00, 0x10}},
+		{name: "array32", header: msgpackHeader(nil, 0x90, 0xdc, 1<<16), expected: []byte{0xdd, 0x00, 0x01, 0x00, 0x00}},
+		{name: "fixstr", header: msgpackStrHeader(nil, 31), expected: []byte{0xbf}},
+		{name: "str8", header: msgpackStrHeader(nil, 255), expected: []byte{0xd9, 0xff}},
+		{name: "str16", header: msgpackStrHeader(nil, 256), expected: []byte{0xda, 0x01, 0x00}},
+		{name: "str32", header: msgpackStrHeader(nil, 1<<16), expected: []byte{0xdb, 0x00, 0x01, 0x00, 0x00}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if !slices.Equal(tc.header, tc.expected) {
+				t.Errorf("expected %x, got %x", tc.expected, tc.header)
+			}
+		})
+	}
+}
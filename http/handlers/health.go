@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"test-lbc/http/models"
+	"test-lbc/pkg"
+	fModels "test-lbc/pkg/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readyTimeout bounds the dependency checks of the readiness probe.
+const readyTimeout = 2 * time.Second
+
+// Healthz tells the process is alive, whatever the state of its dependencies.
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, models.ResponseHealth{
+		Status: fModels.HealthOK,
+	})
+}
+
+// Readyz tells whether the dependencies of the service are ready, answering
+// 503 when one of them is not.
+func Readyz(c *gin.Context, store pkg.StatsStore) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readyTimeout)
+	defer cancel()
+
+	resp := models.ResponseHealth{
+		Status: fModels.HealthOK,
+		Checks: serviceFactory(store).Ready(ctx),
+	}
+	code := http.StatusOK
+	for _, check := range resp.Checks {
+		if check.Status != fModels.HealthOK {
+			slog.WarnContext(ctx, "not ready", "check", check.Name, "detail", check.Detail)
+			resp.Status, code = fModels.HealthUnavailable, http.StatusServiceUnavailable
+		}
+	}
+
+	c.JSON(code, resp)
+}
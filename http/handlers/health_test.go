@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"test-lbc/http/models"
+	"test-lbc/pkg"
+	fModels "test-lbc/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHealthz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/healthz", nil)
+
+	Healthz(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	origFactory := serviceFactory
+	defer func() { serviceFactory = origFactory }()
+
+	testCases := []struct {
+		name           string
+		checks         []fModels.HealthCheck
+		expectedCode   int
+		expectedStatus string
+	}{
+		{
+			name:           "No dependency",
+			expectedCode:   http.StatusOK,
+			expectedStatus: "ok",
+		},
+		{
+			name: "Ready",
+			checks: []fModels.HealthCheck{
+				{Name: "database", Status: fModels.HealthOK},
+				{Name: "schema", Status: fModels.HealthOK, Detail: "version 2"},
+			},
+			expectedCode:   http.StatusOK,
+			expectedStatus: "ok",
+		},
+		{
+			name: "Not ready",
+			checks: []fModels.HealthCheck{
+				{Name: "database", Status: fModels.HealthOK},
+				{Name: "schema", Status: fModels.HealthError, Detail: "1 pending migrations"},
+			},
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedStatus: fModels.HealthUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			serviceFactory = func(store pkg.StatsStore) FizzBuzzService {
+				return &MockService{
+					ReadyFunc: func(ctx context.Context) []fModels.HealthCheck {
+						if _, ok := ctx.Deadline(); !ok {
+							t.Errorf("Expected the checks to have a deadline")
+						}
+						return tc.checks
+					},
+				}
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("GET", "/readyz", nil)
+
+			Readyz(c, nil)
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("Expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+			var resp models.ResponseHealth
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if resp.Status != tc.expectedStatus || len(resp.Checks) != len(tc.checks) {
+				t.Errorf("Unexpected response %v", resp)
+			}
+		})
+	}
+}
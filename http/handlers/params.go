@@ -0,0 +1,402 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"test-lbc/http/models"
+	"test-lbc/pkg"
+	fModels "test-lbc/pkg/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBodyBytes is the maximum size of a JSON request body.
+const maxBodyBytes = 1 << 20
+
+// fizzBuzzRequest holds the parameters of a fizzbuzz request, either decoded
+// from a JSON body or parsed from the query string.
+type fizzBuzzRequest struct {
+	Limit *int           `json:"limit"`
+	Start *int           `json:"start"`
+	End   *int           `json:"end"`
+	Step  *int           `json:"step"`
+	Rules []fModels.Rule `json:"rules"`
+	Mode  fModels.Mode   `json:"mode"`
+
+	// classic rules of a JSON body, the query string ones being read
+	// straight into Rules
+	Int1 *int    `json:"int1"`
+	Int2 *int    `json:"int2"`
+	Str1 *string `json:"str1"`
+	Str2 *string `json:"str2"`
+}
+
+func getFizzBuzzParams(c *gin.Context) (*fModels.FizzBuzzParams, []models.Violation) {
+	if c.ContentType() == "application/json" {
+		var req fizzBuzzRequest
+		if err := decodeJSONBody(c, &req); err != nil {
+			return nil, []models.Violation{bodyViolation(err)}
+		}
+		return req.validate()
+	}
+
+	req, violations := getFizzBuzzQuery(c)
+	if req.Rules == nil && len(violations) > 0 {
+		return nil, violations
+	}
+
+	params, paramsViolations := req.params()
+
+	return params, append(violations, paramsViolations...)
+}
+
+// decodeJSONBody strictly decodes a JSON request body into v: unknown fields
+// and trailing data are rejected.
+func decodeJSONBody(c *gin.Context, v any) error {
+	return decodeJSON(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes), v)
+}
+
+// decodeJSON strictly decodes a JSON value read from r into v.
+func decodeJSON(r io.Reader, v any) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON value")
+	}
+
+	return nil
+}
+
+// bodyViolation returns the violation of a body decodeJSON failed to decode,
+// at the field of the body it stopped at if any.
+func bodyViolation(err error) models.Violation {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return models.NewViolation(models.CodeBodyInvalid, typeErr.Field, "%s must be a JSON %s", typeErr.Field, typeErr.Type.Kind())
+	}
+
+	return models.NewViolation(models.CodeBodyInvalid, "", "%v", err)
+}
+
+// validate validates a request decoded from a JSON body.
+func (req fizzBuzzRequest) validate() (*fModels.FizzBuzzParams, []models.Violation) {
+	if violations := req.classicRules(); len(violations) > 0 {
+		return nil, violations
+	}
+
+	return req.params()
+}
+
+// ValidateParams validates the params of a request of another API, as the
+// ones of a JSON body, and maps them onto the engine params.
+func ValidateParams(limit, start, end, step *int, rules []fModels.Rule, mode fModels.Mode) (*fModels.FizzBuzzParams, []models.Violation) {
+	return fizzBuzzRequest{
+		Limit: limit,
+		Start: start,
+		End:   end,
+		Step:  step,
+		Rules: rules,
+		Mode:  mode,
+	}.validate()
+}
+
+// classicRules maps the int1, int2, str1 and str2 fields of a JSON body onto rules.
+func (req *fizzBuzzRequest) classicRules() []models.Violation {
+	if req.Int1 == nil && req.Int2 == nil && req.Str1 == nil && req.Str2 == nil {
+		return nil
+	}
+	if violations := classicViolations(req.Int1 != nil, req.Int2 != nil, req.Str1 != nil, req.Str2 != nil); len(violations) > 0 {
+		return violations
+	}
+	if req.Rules != nil {
+		return []models.Violation{
+			models.NewViolation(models.CodeParamConflict, "rules", "int1, int2, str1 and str2 can not be used along with rules"),
+		}
+	}
+
+	req.Rules = fModels.ClassicRules(*req.Int1, *req.Int2, *req.Str1, *req.Str2)
+	return nil
+}
+
+// classicViolations returns a violation for every classic param which is not
+// set, when some are.
+func classicViolations(int1, int2, str1, str2 bool) []models.Violation {
+	var violations []models.Violation
+	for _, param := range []struct {
+		name string
+		set  bool
+	}{{"int1", int1}, {"int2", int2}, {"str1", str1}, {"str2", str2}} {
+		if !param.set {
+			violations = append(violations, models.NewViolation(models.CodeParamMissing, param.name, "int1, int2, str1 and str2 are all mandatory"))
+		}
+	}
+
+	return violations
+}
+
+// queryInt returns the value of an optional integer query param, which is 0
+// along with a violation when it can not be parsed.
+func queryInt(c *gin.Context, name string) (*int, *models.Violation) {
+	valueStr := c.Query(name)
+	if valueStr == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		violation := notIntegerViolation(name, valueStr)
+		return &value, &violation
+	}
+
+	return &value, nil
+}
+
+func notIntegerViolation(name, valueStr string) models.Violation {
+	return models.NewViolation(models.CodeParamNotInteger, name, "%s must be an integer, got %q", name, valueStr)
+}
+
+// getFizzBuzzQuery reads a fizzbuzz request from the query string.
+// It returns no rules along with errors when the request is unusable.
+func getFizzBuzzQuery(c *gin.Context) (fizzBuzzRequest, []models.Violation) {
+	var (
+		req = fizzBuzzRequest{
+			Mode: fModels.Mode(c.Query("mode")),
+		}
+
+		violations []models.Violation
+	)
+
+	for _, param := range []struct {
+		name  string
+		value **int
+	}{{"limit", &req.Limit}, {"start", &req.Start}, {"end", &req.End}, {"step", &req.Step}} {
+		var violation *models.Violation
+		if *param.value, violation = queryInt(c, param.name); violation != nil {
+			violations = append(violations, *violation)
+		}
+	}
+
+	req.Rules, violations = getQueryRules(c, violations)
+
+	return req, violations
+}
+
+// getQueryRules reads the rules from the query string, appending their
+// violations to violations. It returns no rules along with their errors only
+// when the rules are unusable.
+func getQueryRules(c *gin.Context, violations []models.Violation) ([]fModels.Rule, []models.Violation) {
+	ruleStrs := c.QueryArray("rule")
+
+	rules, ruleViolations := getClassicRules(c)
+	if rules == nil && len(ruleViolations) > 0 {
+		return nil, ruleViolations
+	}
+	violations = append(violations, ruleViolations...)
+	if len(ruleStrs) > 0 {
+		if rules != nil {
+			return nil, []models.Violation{
+				models.NewViolation(models.CodeParamConflict, "rule", "int1, int2, str1 and str2 can not be used along with rule"),
+			}
+		}
+		for _, ruleStr := range ruleStrs {
+			rule, violation := parseRule(ruleStr)
+			if violation != nil {
+				violations = append(violations, *violation)
+				continue
+			}
+			rules = append(rules, rule)
+		}
+	} else if rules == nil {
+		return nil, []models.Violation{
+			models.NewViolation(models.CodeParamMissing, "rule", "either int1, int2, str1 and str2 or at least one rule are mandatory"),
+		}
+	}
+
+	return rules, violations
+}
+
+// getClassicRules maps the int1, int2, str1 and str2 query params onto rules.
+// It returns no rules when none of them are set.
+func getClassicRules(c *gin.Context) ([]fModels.Rule, []models.Violation) {
+	var (
+		int1Str = c.Query("int1")
+		int2Str = c.Query("int2")
+		str1    = c.Query("str1")
+		str2    = c.Query("str2")
+
+		violations []models.Violation
+	)
+
+	if int1Str == "" && int2Str == "" && str1 == "" && str2 == "" {
+		return nil, nil
+	}
+	if violations := classicViolations(int1Str != "", int2Str != "", str1 != "", str2 != ""); len(violations) > 0 {
+		return nil, violations
+	}
+
+	int1, err := strconv.Atoi(int1Str)
+	if err != nil {
+		violations = append(violations, notIntegerViolation("int1", int1Str))
+	}
+
+	int2, err := strconv.Atoi(int2Str)
+	if err != nil {
+		violations = append(violations, notIntegerViolation("int2", int2Str))
+	}
+
+	return fModels.ClassicRules(int1, int2, str1, str2), violations
+}
+
+// parseRule parses a "div:word" rule, the word being everything after the first colon.
+func parseRule(ruleStr string) (fModels.Rule, *models.Violation) {
+	divisorStr, word, found := strings.Cut(ruleStr, ":")
+	if !found {
+		violation := models.NewViolation(models.CodeParamInvalid, "rule", "%q does not match div:word", ruleStr)
+		return fModels.Rule{}, &violation
+	}
+
+	divisor, err := strconv.Atoi(divisorStr)
+	if err != nil {
+		violation := models.NewViolation(models.CodeParamNotInteger, "rule", "the divisor of rule %q must be an integer", ruleStr)
+		return fModels.Rule{}, &violation
+	}
+
+	return fModels.Rule{Divisor: divisor, Word: word}, nil
+}
+
+// params validates the request and maps it onto the engine params.
+// The range is either 1 to limit or start to end, by step (default 1, or -1 for
+// a descending range). The mode defaults to concat.
+func (req fizzBuzzRequest) params() (*fModels.FizzBuzzParams, []models.Violation) {
+	var (
+		params = &fModels.FizzBuzzParams{
+			Rules: req.Rules,
+			Mode:  req.Mode,
+			Step:  1,
+		}
+
+		violations []models.Violation
+	)
+
+	switch {
+	case req.Limit != nil && (req.Start != nil || req.End != nil):
+		return nil, []models.Violation{
+			models.NewViolation(models.CodeParamConflict, "limit", "limit can not be used along with start and end"),
+		}
+	case req.Limit != nil:
+		params.Start, params.End = 1, *req.Limit
+		if *req.Limit <= 0 {
+			violations = append(violations, models.NewViolation(models.CodeLimitOutOfRange, "limit", "limit must be greater than 0"))
+		}
+	case req.Start != nil && req.End != nil:
+		params.Start, params.End = *req.Start, *req.End
+		if params.Start > params.End {
+			params.Step = -1
+		}
+	default:
+		return nil, []models.Violation{
+			models.NewViolation(models.CodeParamMissing, "limit", "either limit or start and end are mandatory"),
+		}
+	}
+
+	if req.Step != nil {
+		params.Step = *req.Step
+	}
+	if params.Step == 0 {
+		violations = append(violations, models.NewViolation(models.CodeParamInvalid, "step", "step must not be 0"))
+	} else if params.Step > 0 && params.Start > params.End || params.Step < 0 && params.Start < params.End {
+		// a limit out of range leads nowhere whatever the step
+		if len(violations) == 0 {
+			violations = append(violations, models.NewViolation(models.CodeLimitOutOfRange, "step", "step must lead from start to end"))
+		}
+	} else if _, ok := pkg.Len(params.Start, params.End, params.Step); !ok {
+		violations = append(violations, models.NewViolation(models.CodeLimitOutOfRange, "end", "the range from start to end must hold less than 2^63 values"))
+	}
+
+	params.Mode, violations = validateRules(params.Rules, params.Mode, violations)
+
+	return params, violations
+}
+
+// getRulesQuery reads rules and mode from the query string, ignoring range params.
+func getRulesQuery(c *gin.Context) ([]fModels.Rule, fModels.Mode, []models.Violation) {
+	rules, violations := getQueryRules(c, nil)
+	if rules == nil && len(violations) > 0 {
+		return nil, "", violations
+	}
+
+	mode, violations := validateRules(rules, fModels.Mode(c.Query("mode")), violations)
+	return rules, mode, violations
+}
+
+// validateRules appends the rules and mode violations to violations and
+// returns the mode, defaulted to concat.
+func validateRules(rules []fModels.Rule, mode fModels.Mode, violations []models.Violation) (fModels.Mode, []models.Violation) {
+	if mode == "" {
+		mode = fModels.ModeConcat
+	}
+	if !slices.Contains(fModels.Modes, mode) {
+		violations = append(violations, models.NewViolation(models.CodeParamInvalid, "mode", "mode must be one of %v", fModels.Modes))
+	}
+
+	if len(rules) == 0 {
+		violations = append(violations, models.NewViolation(models.CodeParamMissing, "rules", "at least one rule is mandatory"))
+	}
+	if len(rules) > pkg.MaxRules {
+		violations = append(violations, models.NewViolation(models.CodeParamOutOfRange, "rules", "at most %d rules are allowed", pkg.MaxRules))
+	}
+	for i, rule := range rules {
+		if rule.Word == "" {
+			violations = append(violations, models.NewViolation(models.CodeParamMissing, fmt.Sprintf("rules[%d].word", i), "the word of rule %d is mandatory", i+1))
+		}
+	}
+
+	return mode, violations
+}
+
+// periodViolation returns the violation of the period param name that
+// parsePeriod failed to parse.
+func periodViolation(name string, err error) models.Violation {
+	return models.NewViolation(models.CodeParamInvalid, name, "%s must be a positive duration such as 90m, 1h or 7d: %v", name, err)
+}
+
+// maxPeriodDays is the longest period in days, beyond which a duration
+// overflows.
+const maxPeriodDays = math.MaxInt64 / int64(24*time.Hour)
+
+// parsePeriod parses a positive duration such as "90m", "1h" or "7d".
+func parsePeriod(periodStr string) (time.Duration, error) {
+	var (
+		period time.Duration
+		err    error
+	)
+	if daysStr, ok := strings.CutSuffix(periodStr, "d"); ok {
+		var days int64
+		days, err = strconv.ParseInt(daysStr, 10, 64)
+		if err == nil && days > maxPeriodDays {
+			return 0, fmt.Errorf("must be at most %dd", maxPeriodDays)
+		}
+		period = time.Duration(days) * 24 * time.Hour
+	} else {
+		period, err = time.ParseDuration(periodStr)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if period <= 0 {
+		return 0, errors.New("must be positive")
+	}
+
+	return period, nil
+}
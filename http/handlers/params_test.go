@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"test-lbc/http/models"
+	"test-lbc/pkg"
+	"testing"
+	"time"
+
+	fModels "test-lbc/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetFizzBuzzParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name     string
+		url      string
+		body     string
+		expected fModels.FizzBuzzParams
+	}{
+		{
+			name:     "Classic parameters",
+			url:      "/fizzbuzz/run?int1=3&int2=5&limit=15&str1=fizz&str2=buzz",
+			expected: fModels.FizzBuzzParams{Start: 1, End: 15, Step: 1, Rules: fModels.ClassicRules(3, 5, "fizz", "buzz"), Mode: fModels.ModeConcat},
+		},
+		{
+			name:     "Mode parameter",
+			url:      "/fizzbuzz/run?int1=3&int2=3&limit=15&str1=fizz&str2=buzz&mode=product",
+			expected: fModels.FizzBuzzParams{Start: 1, End: 15, Step: 1, Rules: fModels.ClassicRules(3, 3, "fizz", "buzz"), Mode: fModels.ModeProduct},
+		},
+		{
+			name:     "Range parameters",
+			url:      "/fizzbuzz/run?int1=3&int2=5&start=1000&end=1100&str1=fizz&str2=buzz",
+			expected: fModels.FizzBuzzParams{Start: 1000, End: 1100, Step: 1, Rules: fModels.ClassicRules(3, 5, "fizz", "buzz"), Mode: fModels.ModeConcat},
+		},
+		{
+			name:     "Countdown parameters",
+			url:      "/fizzbuzz/run?int1=3&int2=5&start=10&end=-10&str1=fizz&str2=buzz",
+			expected: fModels.FizzBuzzParams{Start: 10, End: -10, Step: -1, Rules: fModels.ClassicRules(3, 5, "fizz", "buzz"), Mode: fModels.ModeConcat},
+		},
+		{
+			name:     "Limit and step parameters",
+			url:      "/fizzbuzz/run?int1=3&int2=5&limit=10&step=3&str1=fizz&str2=buzz",
+			expected: fModels.FizzBuzzParams{Start: 1, End: 10, Step: 3, Rules: fModels.ClassicRules(3, 5, "fizz", "buzz"), Mode: fModels.ModeConcat},
+		},
+		{
+			name: "Rule parameters",
+			url:  "/fizzbuzz/run?limit=15&rule=3:Fizz&rule=5:Buzz&rule=7:Ba:zz",
+			expected: fModels.FizzBuzzParams{Start: 1, End: 15, Step: 1, Rules: []fModels.Rule{
+				{Divisor: 3, Word: "Fizz"}, {Divisor: 5, Word: "Buzz"}, {Divisor: 7, Word: "Ba:zz"},
+			}, Mode: fModels.ModeConcat},
+		},
+		{
+			name: "JSON body",
+			url:  "/fizzbuzz/run",
+			body: `{"start":-5,"end":15,"step":5,"rules":[{"divisor":3,"word":"Fizz"},{"divisor":11,"word":"Bang"}],"mode":"first"}`,
+			expected: fModels.FizzBuzzParams{Start: -5, End: 15, Step: 5, Rules: []fModels.Rule{
+				{Divisor: 3, Word: "Fizz"}, {Divisor: 11, Word: "Bang"},
+			}, Mode: fModels.ModeFirst},
+		},
+		{
+			name:     "JSON body with classic parameters",
+			url:      "/fizzbuzz/run?int1=7&int2=11&limit=77&str1=ignored&str2=ignored",
+			body:     `{"int1":3,"int2":5,"limit":100,"str1":"fi&zz","str2":"bu zz"}`,
+			expected: fModels.FizzBuzzParams{Start: 1, End: 100, Step: 1, Rules: fModels.ClassicRules(3, 5, "fi&zz", "bu zz"), Mode: fModels.ModeConcat},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request, _ = http.NewRequest("POST", tc.url, strings.NewReader(tc.body))
+			if tc.body != "" {
+				c.Request.Header.Set("Content-Type", "application/json")
+			}
+
+			params, violations := getFizzBuzzParams(c)
+			if len(violations) > 0 {
+				t.Fatalf("unexpected violations: %v", violations)
+			}
+			if !reflect.DeepEqual(*params, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, *params)
+			}
+		})
+	}
+}
+
+func TestGetFizzBuzzParamsErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name  string
+		url   string
+		body  string
+		code  models.ErrorCode
+		field string
+	}{
+		{name: "Missing range", url: "/fizzbuzz/run?rule=3:fizz", code: models.CodeParamMissing, field: "limit"},
+		{name: "Limit and range", url: "/fizzbuzz/run?rule=3:fizz&limit=10&start=1&end=10", code: models.CodeParamConflict, field: "limit"},
+		{name: "Negative limit", url: "/fizzbuzz/run?rule=3:fizz&limit=-1", code: models.CodeLimitOutOfRange, field: "limit"},
+		{name: "Zero step", url: "/fizzbuzz/run?rule=3:fizz&start=1&end=10&step=0", code: models.CodeParamInvalid, field: "step"},
+		{name: "Wrong step direction", url: "/fizzbuzz/run?rule=3:fizz&start=1&end=10&step=-1", code: models.CodeLimitOutOfRange, field: "step"},
+		{name: "Too large range", url: "/fizzbuzz/run?rule=3:fizz&start=-9223372036854775808&end=9223372036854775807", code: models.CodeLimitOutOfRange, field: "end"},
+		{name: "Invalid end", url: "/fizzbuzz/run?rule=3:fizz&start=1&end=ten", code: models.CodeParamNotInteger, field: "end"},
+		{name: "Missing rule", url: "/fizzbuzz/run?limit=10", code: models.CodeParamMissing, field: "rule"},
+		{name: "Missing classic param", url: "/fizzbuzz/run?limit=10&int1=3&int2=5&str1=fizz", code: models.CodeParamMissing, field: "str2"},
+		{name: "Invalid rule", url: "/fizzbuzz/run?limit=10&rule=fizz", code: models.CodeParamInvalid, field: "rule"},
+		{name: "Invalid mode", url: "/fizzbuzz/run?limit=10&rule=3:fizz&mode=lcm", code: models.CodeParamInvalid, field: "mode"},
+		{name: "Too many rules", url: "/fizzbuzz/run?limit=10" + strings.Repeat("&rule=3:fizz", pkg.MaxRules+1), code: models.CodeParamOutOfRange, field: "rules"},
+		{name: "Empty word", url: "/fizzbuzz/run?limit=10&rule=3:", code: models.CodeParamMissing, field: "rules[0].word"},
+		{name: "Empty body", url: "/fizzbuzz/run", body: " ", code: models.CodeBodyInvalid},
+		{name: "Unknown field", url: "/fizzbuzz/run", body: `{"int1":3,"int2":5,"limit":100,"str1":"fizz","str2":"buzz","int3":7}`, code: models.CodeBodyInvalid},
+		{name: "Trailing data", url: "/fizzbuzz/run", body: `{"int1":3,"int2":5,"limit":100,"str1":"fizz","str2":"buzz"}{}`, code: models.CodeBodyInvalid},
+		{name: "Missing classic field", url: "/fizzbuzz/run", body: `{"int1":3,"int2":5,"limit":100,"str1":"fizz"}`, code: models.CodeParamMissing, field: "str2"},
+		{name: "Classic fields and rules", url: "/fizzbuzz/run", body: `{"int1":3,"int2":5,"limit":100,"str1":"fizz","str2":"buzz","rules":[{"divisor":7,"word":"bazz"}]}`, code: models.CodeParamConflict, field: "rules"},
+		{name: "Wrong type", url: "/fizzbuzz/run", body: `{"int1":"3","int2":5,"limit":100,"str1":"fizz","str2":"buzz"}`, code: models.CodeBodyInvalid, field: "int1"},
+		{name: "Too large body", url: "/fizzbuzz/run", body: `{"limit":100,"rules":[{"divisor":3,"word":"` + strings.Repeat("a", maxBodyBytes) + `"}]}`, code: models.CodeBodyInvalid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request, _ = http.NewRequest("POST", tc.url, strings.NewReader(tc.body))
+			if tc.body != "" {
+				c.Request.Header.Set("Content-Type", "application/json")
+			}
+
+			_, violations := getFizzBuzzParams(c)
+			if len(violations) == 0 {
+				t.Fatalf("expected violations, got none")
+			}
+			if violations[0].Code != tc.code || violations[0].Field != tc.field {
+				t.Errorf("expected a %s violation of %q, got %v", tc.code, tc.field, violations)
+			}
+		})
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	testCases := []struct {
+		period   string
+		expected time.Duration
+		ok       bool
+	}{
+		{period: "90m", expected: 90 * time.Minute, ok: true},
+		{period: "1h30m", expected: 90 * time.Minute, ok: true},
+		{period: "7d", expected: 7 * 24 * time.Hour, ok: true},
+		{period: "106751d", expected: 106751 * 24 * time.Hour, ok: true},
+		{period: "106752d"},
+		{period: "213504d"},
+		{period: "9223372036854775807d"},
+		{period: "0s"},
+		{period: "-2d"},
+		{period: "d"},
+		{period: "week"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.period, func(t *testing.T) {
+			period, err := parsePeriod(tc.period)
+			if (err == nil) != tc.ok || period != tc.expected {
+				t.Errorf("expected %s, %t, got %s, %v", tc.expected, tc.ok, period, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+	"test-lbc/http/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AbortWithProblem answers the problem as application/problem+json.
+func AbortWithProblem(c *gin.Context, problem models.Problem) {
+	c.Header("Content-Type", models.ProblemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// abortWithViolations answers the violations of an invalid request.
+func abortWithViolations(c *gin.Context, violations []models.Violation) {
+	AbortWithProblem(c, models.NewValidationProblem(violations))
+}
+
+// abortWithError answers an internal error.
+func abortWithError(c *gin.Context, err error) {
+	AbortWithProblem(c, models.NewProblem(http.StatusInternalServerError, err.Error()))
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"test-lbc/http/models"
+	"test-lbc/pkg"
+	"test-lbc/prometheus"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// maxStreamInterval is the maximum pacing between two values of a stream.
+const maxStreamInterval = time.Minute
+
+// FizzBuzzStream streams the sequence as Server-Sent Events, one event per
+// value identified by its index, followed by an end event.
+func FizzBuzzStream(c *gin.Context, store pkg.StatsStore) {
+	result, interval, ok := startStream(c, store, "stream")
+	if !ok {
+		return
+	}
+
+	liftWriteDeadline(c)
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+	ctx := c.Request.Context()
+	for i, value := range pace(ctx, result, interval) {
+		data, err := json.Marshal(value)
+		if err == nil {
+			_, err = fmt.Fprintf(c.Writer, "id: %d\ndata: %s\n\n", i, data)
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "failed to write fizzbuzz stream", "error", err)
+			return
+		}
+		c.Writer.Flush()
+	}
+	if ctx.Err() == nil {
+		io.WriteString(c.Writer, "event: end\ndata: done\n\n")
+		c.Writer.Flush()
+	}
+}
+
+// FizzBuzzStreamWS streams the sequence over a WebSocket, one JSON message
+// per value, then closes it. Only the pages of the API host and of
+// allowedOrigins can open it. The WebSocket is also closed once done is, as
+// the server no longer tracks the hijacked connection.
+func FizzBuzzStreamWS(c *gin.Context, store pkg.StatsStore, allowedOrigins []string, done <-chan struct{}) {
+	result, interval, ok := startStream(c, store, "stream_ws")
+	if !ok {
+		return
+	}
+
+	served := false
+	websocket.Server{Handshake: checkOrigin(allowedOrigins), Handler: func(ws *websocket.Conn) {
+		served = true
+		defer ws.Close()
+		// the connection is hijacked with the deadlines of the server timeouts
+		ws.SetDeadline(time.Time{})
+
+		// the stream stops once the client closes the connection, or on shutdown
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+		go func() {
+			io.Copy(io.Discard, ws)
+			cancel()
+		}()
+		go func() {
+			select {
+			case <-done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		for i, value := range pace(ctx, result, interval) {
+			if err := websocket.JSON.Send(ws, models.ResponseStreamEvent{Index: i, Value: value}); err != nil {
+				slog.WarnContext(ctx, "failed to write fizzbuzz stream", "error", err)
+				return
+			}
+		}
+	}}.ServeHTTP(c.Writer, c.Request)
+	// a failed handshake gives up on the sequence, which ends its span
+	if !served {
+		for range result {
+			break
+		}
+	}
+}
+
+// checkOrigin returns a WebSocket handshake accepting the pages of the
+// request host and of allowedOrigins, or any page when they hold "*". A
+// client sending no Origin is not a browser, and is accepted.
+func checkOrigin(allowedOrigins []string) func(*websocket.Config, *http.Request) error {
+	return func(config *websocket.Config, req *http.Request) (err error) {
+		config.Origin, err = websocket.Origin(config, req)
+		if err != nil || config.Origin == nil {
+			return err
+		}
+
+		origin := config.Origin.Scheme + "://" + config.Origin.Host
+		if strings.EqualFold(config.Origin.Host, req.Host) || slices.ContainsFunc(allowedOrigins, func(allowed string) bool {
+			return allowed == "*" || strings.EqualFold(allowed, origin)
+		}) {
+			return nil
+		}
+
+		return fmt.Errorf("origin %q is not allowed", origin)
+	}
+}
+
+// startStream validates the stream request and returns its sequence, from
+// the value following the last received one, and its pacing. It answers the
+// errors itself, returning false.
+func startStream(c *gin.Context, store pkg.StatsStore, name string) (iter.Seq2[int, string], time.Duration, bool) {
+	prometheus.IncRequest(name)
+	params, violations := getFizzBuzzParams(c)
+
+	interval, err := time.ParseDuration(c.DefaultQuery("interval", "0s"))
+	if err != nil {
+		violations = append(violations, models.NewViolation(models.CodeParamInvalid, "interval", "interval must be a duration such as 500ms: %v", err))
+	} else if interval < 0 || interval > maxStreamInterval {
+		violations = append(violations, models.NewViolation(models.CodeParamOutOfRange, "interval", "interval must be between 0s and %v", maxStreamInterval))
+	}
+
+	length := 0
+	if params != nil {
+		length, _ = pkg.Len(params.Start, params.End, params.Step)
+	}
+
+	// browsers can not set the header of a WebSocket, nor of the first
+	// request of an EventSource
+	from := 0
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	if lastEventID != "" {
+		id, err := strconv.Atoi(lastEventID)
+		switch {
+		case err != nil:
+			violations = append(violations, notIntegerViolation("Last-Event-ID", lastEventID))
+		case id < 0:
+			violations = append(violations, models.NewViolation(models.CodeParamOutOfRange, "Last-Event-ID", "Last-Event-ID must be positive"))
+		case params != nil && id >= length:
+			// an ID beyond the sequence was never sent, and the next index
+			// could overflow
+			violations = append(violations, models.NewViolation(models.CodeParamOutOfRange, "Last-Event-ID", "Last-Event-ID must be lower than %d, the number of values", length))
+		default:
+			from = id + 1
+		}
+	}
+
+	if len(violations) > 0 {
+		prometheus.IncStats(name, "error")
+		slog.WarnContext(c.Request.Context(), "failed to stream fizzbuzz", "violations", violations)
+		abortWithViolations(c, violations)
+		return nil, 0, false
+	}
+	prometheus.ObserveRequestedValues(name, length)
+
+	result, err := serviceFactory(store).Stream(c.Request.Context(), *params, from)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to save stats", "error", err)
+		prometheus.IncStats(name, "error_on_stat_save")
+	} else {
+		prometheus.IncStats(name, "success")
+	}
+
+	return result, interval, true
+}
+
+// pace yields the values of seq, waiting interval between two of them, until
+// ctx is done.
+func pace(ctx context.Context, seq iter.Seq2[int, string], interval time.Duration) iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		var tick <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		first := true
+		for i, value := range seq {
+			if !first && tick != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-tick:
+				}
+			} else if ctx.Err() != nil {
+				return
+			}
+			first = false
+			if !yield(i, value) {
+				return
+			}
+		}
+	}
+}
+
+// liftWriteDeadline lifts the server write timeout from the response, whose
+// sequence may be long to write.
+func liftWriteDeadline(c *gin.Context) {
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+}
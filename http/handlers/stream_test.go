@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"test-lbc/http/models"
+	"test-lbc/pkg"
+	fModels "test-lbc/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// mockStream mocks a service streaming the sequence of the params, and
+// records the index it is resumed from.
+func mockStream(from *int) func(store pkg.StatsStore) FizzBuzzService {
+	return func(store pkg.StatsStore) FizzBuzzService {
+		return &MockService{
+			StreamFunc: func(params fModels.FizzBuzzParams, f int) (iter.Seq2[int, string], error) {
+				*from = f
+				return func(yield func(int, string) bool) {
+					for i, value := range pkg.Sequence(params) {
+						if i >= f && !yield(i, value) {
+							return
+						}
+					}
+				}, nil
+			},
+		}
+	}
+}
+
+func TestFizzBuzzStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	origFactory := serviceFactory
+	defer func() { serviceFactory = origFactory }()
+
+	var from int
+	serviceFactory = mockStream(&from)
+
+	t.Run("Success", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/fizzbuzz/stream?int1=3&int2=5&limit=4&str1=fizz&str2=buzz", nil)
+		c.Request.Header.Set("Last-Event-ID", "1")
+
+		FizzBuzzStream(c, nil)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if from != 2 {
+			t.Errorf("Expected to resume from 2, got %d", from)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "text/event-stream" {
+			t.Errorf("Expected an event stream, got %s", contentType)
+		}
+		expected := "id: 2\ndata: \"fizz\"\n\nid: 3\ndata: \"4\"\n\nevent: end\ndata: done\n\n"
+		if w.Body.String() != expected {
+			t.Errorf("Expected body %q, got %q", expected, w.Body.String())
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequestWithContext(ctx, "GET", "/fizzbuzz/stream?int1=3&int2=5&limit=1000000000&str1=fizz&str2=buzz", nil)
+
+		FizzBuzzStream(c, nil)
+
+		if strings.Contains(w.Body.String(), "data:") {
+			t.Errorf("Expected no event, got %q", w.Body.String())
+		}
+	})
+
+	testCases := []struct {
+		name        string
+		query       string
+		lastEventID string
+	}{
+		{name: "Invalid parameters", query: "int1=abc&int2=5&limit=4&str1=fizz&str2=buzz"},
+		{name: "Invalid interval", query: "int1=3&int2=5&limit=4&str1=fizz&str2=buzz&interval=fast"},
+		{name: "Negative interval", query: "int1=3&int2=5&limit=4&str1=fizz&str2=buzz&interval=-1s"},
+		{name: "Too long interval", query: "int1=3&int2=5&limit=4&str1=fizz&str2=buzz&interval=2m"},
+		{name: "Invalid Last-Event-ID", query: "int1=3&int2=5&limit=4&str1=fizz&str2=buzz", lastEventID: "abc"},
+		{name: "Negative last_event_id", query: "int1=3&int2=5&limit=4&str1=fizz&str2=buzz&last_event_id=-1"},
+		{name: "Last-Event-ID beyond the sequence", query: "int1=3&int2=5&limit=4&str1=fizz&str2=buzz", lastEventID: "4"},
+		{name: "Overflowing Last-Event-ID", query: "int1=3&int2=5&limit=4&str1=fizz&str2=buzz", lastEventID: strconv.Itoa(math.MaxInt)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("GET", "/fizzbuzz/stream?"+tc.query, nil)
+			if tc.lastEventID != "" {
+				c.Request.Header.Set("Last-Event-ID", tc.lastEventID)
+			}
+
+			FizzBuzzStream(c, nil)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestFizzBuzzStreamWS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	origFactory := serviceFactory
+	defer func() { serviceFactory = origFactory }()
+
+	var from int
+	serviceFactory = mockStream(&from)
+
+	done := make(chan struct{})
+	router := gin.New()
+	router.GET("/fizzbuzz/stream/ws", func(c *gin.Context) {
+		FizzBuzzStreamWS(c, nil, []string{"https://app.example.com"}, done)
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+	baseURL := strings.Replace(server.URL, "http", "ws", 1) + "/fizzbuzz/stream/ws?int1=3&int2=5&str1=fizz&str2=buzz"
+
+	t.Run("Stream", func(t *testing.T) {
+		ws, err := websocket.Dial(baseURL+"&limit=6&interval=1ms&last_event_id=2", "", server.URL)
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		defer ws.Close()
+
+		// the server closes the connection once the sequence is sent
+		var events []models.ResponseStreamEvent
+		for {
+			var event models.ResponseStreamEvent
+			if err := websocket.JSON.Receive(ws, &event); err != nil {
+				break
+			}
+			events = append(events, event)
+		}
+
+		expected := []models.ResponseStreamEvent{{Index: 3, Value: "4"}, {Index: 4, Value: "buzz"}, {Index: 5, Value: "fizz"}}
+		if !reflect.DeepEqual(events, expected) {
+			t.Errorf("Expected %v, got %v", expected, events)
+		}
+		if from != 3 {
+			t.Errorf("Expected to resume from 3, got %d", from)
+		}
+	})
+
+	t.Run("Origin", func(t *testing.T) {
+		for _, tc := range []struct {
+			origin string
+			ok     bool
+		}{
+			{server.URL, true},
+			{"https://app.example.com", true},
+			{"https://evil.example.com", false},
+		} {
+			ws, err := websocket.Dial(baseURL+"&limit=1", "", tc.origin)
+			if (err == nil) != tc.ok {
+				t.Errorf("Expected origin %s to be allowed: %t, got %v", tc.origin, tc.ok, err)
+			}
+			if err == nil {
+				ws.Close()
+			}
+		}
+	})
+
+	t.Run("Shutdown", func(t *testing.T) {
+		ws, err := websocket.Dial(baseURL+"&limit=100&interval=1m", "", server.URL)
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		defer ws.Close()
+
+		var event models.ResponseStreamEvent
+		if err := websocket.JSON.Receive(ws, &event); err != nil {
+			t.Fatalf("Failed to receive the first value: %v", err)
+		}
+
+		// the stream is closed without waiting for the next value
+		close(done)
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err := websocket.JSON.Receive(ws, &event); err == nil || errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Errorf("Expected the stream to be closed, got %v", err)
+		}
+	})
+}
+
+func TestPace(t *testing.T) {
+	seq := pkg.Sequence(fModels.FizzBuzzParams{Start: 1, End: 100, Step: 1})
+
+	t.Run("Interval", func(t *testing.T) {
+		start := time.Now()
+		n := 0
+		for range pace(context.Background(), seq, 10*time.Millisecond) {
+			if n++; n == 3 {
+				break
+			}
+		}
+		// no wait before the first value
+		if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+			t.Errorf("Expected 3 values to take 20ms at least, got %v", elapsed)
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		n := 0
+		for range pace(ctx, seq, 0) {
+			if n++; n == 3 {
+				cancel()
+			}
+		}
+		if n != 3 {
+			t.Errorf("Expected 3 values before cancellation, got %d", n)
+		}
+	})
+}
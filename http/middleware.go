@@ -0,0 +1,156 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"test-lbc/http/handlers"
+	"test-lbc/http/models"
+	"test-lbc/logging"
+	"test-lbc/prometheus"
+	"test-lbc/tracing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// RequestIDHeader is the header holding the ID of a request, given by the
+// client or a proxy, or generated, and answered back.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the maximum length of a request ID given by a client.
+const maxRequestIDLength = 128
+
+// requestID propagates the request ID given by the client, or a new one, in
+// the response and in the context of the request, so that its logs hold it.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// validRequestID tells whether a request ID given by a client can be logged
+// as is: not too long, and only printable ASCII characters without spaces.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// untracedRoutes are the routes of the probes, whose traces would only be noise.
+var untracedRoutes = []string{"/healthz", "/readyz"}
+
+// traces starts the span of every request, in the trace of the caller given by
+// its traceparent header, if any. The middlewares following it log and
+// measure the request in its span: the span is dropped from the request
+// context once it returns.
+func traces() gin.HandlerFunc {
+	return otelgin.Middleware(tracing.ServiceName, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		return !slices.Contains(untracedRoutes, c.FullPath())
+	}))
+}
+
+// accessLog logs every request once answered, as an error when the server
+// failed and as a warning when the client did.
+func accessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.String("query", c.Request.URL.RawQuery),
+			slog.Int("status", status),
+			slog.Float64("latency", time.Since(start).Seconds()),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if apiKey := c.GetString(APIKeyContextKey); apiKey != "" {
+			attrs = append(attrs, slog.String("api_key", apiKey))
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// unmatchedRoute is the route label of the requests matching no route, so
+// that unknown paths do not grow the metrics.
+const unmatchedRoute = "unmatched"
+
+// metrics measures the requests being served, and the duration and response
+// size of every request by route.
+func metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		prometheus.AddRequestsInFlight(1)
+		defer prometheus.AddRequestsInFlight(-1)
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		prometheus.ObserveRequest(c.Request.Context(), route, c.Request.Method, c.Writer.Status(), time.Since(start), max(c.Writer.Size(), 0))
+	}
+}
+
+// recovery answers a request whose handler panicked with an internal error,
+// logging the panic along with its stack.
+func recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			// the handler aborted the response on purpose
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+
+			slog.ErrorContext(c.Request.Context(), "panic serving request", "error", err, "stack", string(debug.Stack()))
+			if c.Writer.Written() {
+				// the response started: it can only be cut short
+				c.Abort()
+				return
+			}
+			handlers.AbortWithProblem(c, models.NewProblem(http.StatusInternalServerError, "internal error"))
+		}()
+
+		c.Next()
+	}
+}
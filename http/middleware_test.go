@@ -0,0 +1,156 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test-lbc/http/models"
+	"test-lbc/logging"
+	"test-lbc/prometheus"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddlewares(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatJSON, "info")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	spans := tracetest.NewInMemoryExporter()
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)))
+	defer otel.SetTextMapPropagator(otel.GetTextMapPropagator())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	router := gin.New()
+	router.Use(requestID(), traces(), accessLog(), metrics(), recovery())
+	router.GET("/ok/:id", func(c *gin.Context) {
+		slog.InfoContext(c.Request.Context(), "handled")
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+	router.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// records returns the JSON records logged since the last call.
+	records := func() []map[string]any {
+		t.Helper()
+		defer buf.Reset()
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			records = append(records, record)
+		}
+		return records
+	}
+	get := func(path, requestID string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		if requestID != "" {
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Given request ID", func(t *testing.T) {
+		w := get("/ok/1?limit=10", "req-1")
+		if w.Header().Get(RequestIDHeader) != "req-1" {
+			t.Errorf("expected the request ID to be answered, got %q", w.Header().Get(RequestIDHeader))
+		}
+		logged := records()
+		if len(logged) != 2 || logged[0]["msg"] != "handled" || logged[0]["request_id"] != "req-1" {
+			t.Fatalf("expected the handler record to hold the request ID, got %v", logged)
+		}
+		access := logged[1]
+		if access["msg"] != "request" || access["request_id"] != "req-1" || access["route"] != "/ok/:id" || access["query"] != "limit=10" ||
+			access["status"] != 200.0 || access["level"] != "INFO" || access["latency"] == nil {
+			t.Errorf("unexpected access log %v", access)
+		}
+	})
+
+	t.Run("Generated request ID", func(t *testing.T) {
+		for _, requestID := range []string{"", "with space", strings.Repeat("a", maxRequestIDLength+1)} {
+			w := get("/ok/1", requestID)
+			if id := w.Header().Get(RequestIDHeader); len(id) != 32 {
+				t.Errorf("expected a generated request ID instead of %q, got %q", requestID, id)
+			}
+			records()
+		}
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		w := get("/panic", "req-2")
+		if w.Code != http.StatusInternalServerError || w.Header().Get("Content-Type") != models.ProblemContentType {
+			t.Errorf("expected an internal error problem, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		logged := records()
+		if len(logged) != 2 || logged[0]["error"] != "boom" || logged[0]["stack"] == nil || logged[0]["request_id"] != "req-2" {
+			t.Errorf("expected the panic to be logged, got %v", logged)
+		}
+		if logged[1]["status"] != 500.0 || logged[1]["level"] != "ERROR" {
+			t.Errorf("expected an error access log, got %v", logged[1])
+		}
+	})
+
+	t.Run("Trace", func(t *testing.T) {
+		spans.Reset()
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/ok/1", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		router.ServeHTTP(w, req)
+		get("/healthz", "")
+
+		logged := records()
+		if logged[0]["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || logged[1]["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("expected the records to hold the trace ID of the caller, got %v", logged)
+		}
+		if logged[2]["trace_id"] != nil {
+			t.Errorf("expected the probe not to be traced, got %v", logged[2])
+		}
+		ended := spans.GetSpans()
+		if len(ended) != 1 || ended[0].Name != "GET /ok/:id" || ended[0].Parent.SpanID().String() != "00f067aa0ba902b7" {
+			t.Fatalf("expected a span child of the caller one, got %v", ended)
+		}
+		if logged[1]["span_id"] != ended[0].SpanContext.SpanID().String() {
+			t.Errorf("expected the access log to hold the request span ID, got %v", logged[1])
+		}
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		get("/ok/1", "")
+		get("/unknown/path", "")
+		records()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/metrics", nil)
+		prometheus.NewServer("", nil).Handler.ServeHTTP(w, req)
+		for _, metric := range []string{
+			`fizzbuzz_http_request_duration_seconds_count{method="GET",route="/ok/:id",status="200"}`,
+			`fizzbuzz_http_request_duration_seconds_count{method="GET",route="/panic",status="500"}`,
+			`fizzbuzz_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"}`,
+			`fizzbuzz_http_response_size_bytes_sum{route="/ok/:id"}`,
+			`fizzbuzz_http_requests_in_flight 0`,
+		} {
+			if !strings.Contains(w.Body.String(), metric) {
+				t.Errorf("expected metric %s, got\n%s", metric, w.Body.String())
+			}
+		}
+	})
+}
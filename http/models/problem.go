@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ProblemContentType is the media type of the error responses.
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix prefixes the type URI of every problem.
+const problemTypePrefix = "urn:fizzbuzz:problem:"
+
+// problemTypes are the type URI suffixes of the problems, by status. A
+// problem of another status is typed about:blank.
+var problemTypes = map[int]string{
+	http.StatusBadRequest:          "validation-error",
+	http.StatusUnauthorized:        "unauthorized",
+	http.StatusForbidden:           "forbidden",
+	http.StatusNotAcceptable:       "not-acceptable",
+	http.StatusTooManyRequests:     "rate-limited",
+	http.StatusInternalServerError: "internal-error",
+}
+
+// ErrorCode is the stable, machine-readable code of a violation.
+type ErrorCode string
+
+const (
+	// CodeParamMissing is a mandatory param which is not given.
+	CodeParamMissing ErrorCode = "param_missing"
+	// CodeParamNotInteger is a param which should be an integer.
+	CodeParamNotInteger ErrorCode = "param_not_integer"
+	// CodeParamInvalid is a param whose value is not allowed.
+	CodeParamInvalid ErrorCode = "param_invalid"
+	// CodeParamConflict is a param which can not be given along with another one.
+	CodeParamConflict ErrorCode = "param_conflict"
+	// CodeParamOutOfRange is a param out of its bounds.
+	CodeParamOutOfRange ErrorCode = "param_out_of_range"
+	// CodeLimitOutOfRange is a limit, or a range, leading to no value or to
+	// more values than allowed.
+	CodeLimitOutOfRange ErrorCode = "limit_out_of_range"
+	// CodeBodyInvalid is a body which can not be decoded.
+	CodeBodyInvalid ErrorCode = "body_invalid"
+)
+
+// Problem is a RFC 7807 problem details error response.
+type Problem struct {
+	// Type is the URI identifying the kind of problem
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Errors are the violations of an invalid request
+	Errors []Violation `json:"errors,omitempty"`
+}
+
+// Violation is a single reason for a request to be invalid.
+type Violation struct {
+	Code ErrorCode `json:"code"`
+	// Field is the param, or the path in the JSON body, at fault, if any
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func NewViolation(code ErrorCode, field, format string, args ...any) Violation {
+	return Violation{
+		Code:    code,
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (v Violation) String() string {
+	if v.Field == "" {
+		return fmt.Sprintf("%s (%s)", v.Message, v.Code)
+	}
+
+	return fmt.Sprintf("%s: %s (%s)", v.Field, v.Message, v.Code)
+}
+
+// NewProblem returns the problem answered with status, typed after it.
+func NewProblem(status int, detail string) Problem {
+	problemType := "about:blank"
+	if suffix, ok := problemTypes[status]; ok {
+		problemType = problemTypePrefix + suffix
+	}
+
+	return Problem{
+		Type:   problemType,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// NewValidationProblem returns the problem answered to an invalid request.
+func NewValidationProblem(violations []Violation) Problem {
+	problem := NewProblem(http.StatusBadRequest, "the request is invalid, see errors")
+	problem.Errors = violations
+
+	return problem
+}
@@ -1,5 +1,50 @@
 package models
 
-type ResponseError struct {
-	Errors []string `json:"errors"`
+import fModels "test-lbc/pkg/models"
+
+type ResponseHealth struct {
+	Status string                `json:"status"`
+	Checks []fModels.HealthCheck `json:"checks,omitempty"`
+}
+
+// ResponseMostRequested is the most requested request. A classic request
+// also holds the int1, int2, limit, str1 and str2 fields it was answered with
+// before rules and ranges.
+type ResponseMostRequested struct {
+	fModels.FizzBuzzStats
+	Int1  *int    `json:"int1,omitempty"`
+	Int2  *int    `json:"int2,omitempty"`
+	Limit *int    `json:"limit,omitempty"`
+	Str1  *string `json:"str1,omitempty"`
+	Str2  *string `json:"str2,omitempty"`
+}
+
+// NewResponseMostRequested returns the response of stats, nil when there
+// are none.
+func NewResponseMostRequested(stats *fModels.FizzBuzzStats) *ResponseMostRequested {
+	if stats == nil {
+		return nil
+	}
+
+	resp := &ResponseMostRequested{FizzBuzzStats: *stats}
+	if len(resp.Rules) == 2 && resp.Mode == fModels.ModeConcat && resp.Start == 1 && resp.Step == 1 {
+		resp.Int1, resp.Str1 = &resp.Rules[0].Divisor, &resp.Rules[0].Word
+		resp.Int2, resp.Str2 = &resp.Rules[1].Divisor, &resp.Rules[1].Word
+		resp.Limit = &resp.End
+	}
+
+	return resp
+}
+
+// ResponseBatchItem is the answer to a request of a batch: either its result
+// or its violations.
+type ResponseBatchItem struct {
+	Result []string    `json:"result,omitempty"`
+	Errors []Violation `json:"errors,omitempty"`
+}
+
+// ResponseStreamEvent is a value of a sequence streamed over a WebSocket.
+type ResponseStreamEvent struct {
+	Index int    `json:"index"`
+	Value string `json:"value"`
 }
@@ -0,0 +1,197 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"test-lbc/http/handlers"
+	"test-lbc/http/models"
+	"test-lbc/prometheus"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyContextKey is the key of the API key of the client in the gin
+// context, set once the client is authenticated. The rate limits are kept
+// per API key, or per client IP when there is none.
+const APIKeyContextKey = "apiKey"
+
+// sweepInterval is the interval between two removals of the full buckets.
+const sweepInterval = time.Minute
+
+// RateLimit is a token bucket: up to Burst requests at once, refilled at
+// Rate requests per second.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseRateLimit parses a "<n>/<period>[:<burst>]" rate limit such as "10/s"
+// or "600/m:50", allowing n requests per period. The burst defaults to n.
+func ParseRateLimit(limitStr string) (RateLimit, error) {
+	rateStr, burstStr, hasBurst := strings.Cut(limitStr, ":")
+	nStr, periodStr, found := strings.Cut(rateStr, "/")
+	if !found {
+		return RateLimit{}, fmt.Errorf("%q does not match <n>/<period>[:<burst>]", limitStr)
+	}
+
+	n, err := strconv.Atoi(nStr)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	// a bare unit is a single one of it
+	if periodStr != "" && !strings.ContainsAny(periodStr[:1], "0123456789") {
+		periodStr = "1" + periodStr
+	}
+	period, err := time.ParseDuration(periodStr)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	if n < 1 || period <= 0 {
+		return RateLimit{}, errors.New("the number of requests and the period must be positive")
+	}
+
+	limit := RateLimit{Rate: float64(n) / period.Seconds(), Burst: n}
+	if hasBurst {
+		if limit.Burst, err = strconv.Atoi(burstStr); err != nil {
+			return RateLimit{}, err
+		}
+		if limit.Burst < 1 {
+			return RateLimit{}, errors.New("the burst must be positive")
+		}
+	}
+
+	return limit, nil
+}
+
+// bucket is the token bucket of a client, holding tokens at the given time.
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// rateLimiter keeps a token bucket per client.
+type rateLimiter struct {
+	limit RateLimit
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// take takes a token from the bucket of the client, and tells whether there
+// was one. It returns the tokens left, the time until a token is available
+// when there was none, and the time until the bucket is full.
+func (l *rateLimiter) take(key string) (ok bool, remaining int, retryAfter, reset time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: float64(l.limit.Burst), at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.tokensAt(b, now)
+	b.at = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		ok = true
+	} else {
+		retryAfter = l.duration(1 - b.tokens)
+	}
+
+	return ok, int(b.tokens), retryAfter, l.duration(float64(l.limit.Burst) - b.tokens)
+}
+
+// wait returns the time until the bucket of the client holds a token, 0 when
+// it holds one, without taking it.
+func (l *rateLimiter) wait(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, found := l.buckets[key]
+	if !found {
+		return 0
+	}
+	if tokens := l.tokensAt(b, l.now()); tokens < 1 {
+		return l.duration(1 - tokens)
+	}
+
+	return 0
+}
+
+// tokensAt returns the tokens of the bucket at the given time.
+func (l *rateLimiter) tokensAt(b *bucket, now time.Time) float64 {
+	return min(float64(l.limit.Burst), b.tokens+now.Sub(b.at).Seconds()*l.limit.Rate)
+}
+
+// duration returns the time needed to refill the given tokens.
+func (l *rateLimiter) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / l.limit.Rate * float64(time.Second))
+}
+
+// sweep removes the full buckets, which are the same as missing ones.
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if l.tokensAt(b, now) >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// rateLimit limits the requests of every client to the routes having a
+// limiter, answering 429 beyond it. The RateLimit-* headers tell the client
+// its current limit.
+func rateLimit(limiters map[string]*rateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		limiter, ok := limiters[route]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		key := "ip:" + c.ClientIP()
+		if apiKey := c.GetString(APIKeyContextKey); apiKey != "" {
+			key = "key:" + apiKey
+		}
+		ok, remaining, retryAfter, reset := limiter.take(key)
+		c.Header("RateLimit-Limit", strconv.Itoa(limiter.limit.Burst))
+		c.Header("RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(seconds(reset)))
+		if !ok {
+			prometheus.IncRateLimited(route)
+			c.Header("Retry-After", strconv.Itoa(seconds(retryAfter)))
+			handlers.AbortWithProblem(c, models.NewProblem(http.StatusTooManyRequests, fmt.Sprintf("rate limit exceeded, retry in %ds", seconds(retryAfter))))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// seconds rounds d up to whole seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
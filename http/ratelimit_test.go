@@ -0,0 +1,132 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	testCases := []struct {
+		limitStr string
+		expected RateLimit
+		wantErr  bool
+	}{
+		{limitStr: "10/s", expected: RateLimit{Rate: 10, Burst: 10}},
+		{limitStr: "600/m:50", expected: RateLimit{Rate: 10, Burst: 50}},
+		{limitStr: "30/2m", expected: RateLimit{Rate: 0.25, Burst: 30}},
+		{limitStr: "10", wantErr: true},
+		{limitStr: "ten/s", wantErr: true},
+		{limitStr: "10/fortnight", wantErr: true},
+		{limitStr: "0/s", wantErr: true},
+		{limitStr: "10/s:0", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.limitStr, func(t *testing.T) {
+			limit, err := ParseRateLimit(tc.limitStr)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %t, got %v", tc.wantErr, err)
+			}
+			if limit != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, limit)
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := newRateLimiter(RateLimit{Rate: 2, Burst: 3})
+	limiter.now = func() time.Time { return now }
+
+	for i := range 3 {
+		if ok, remaining, _, _ := limiter.take("a"); !ok || remaining != 2-i {
+			t.Fatalf("expected token %d with %d remaining, got %t, %d", i, 2-i, ok, remaining)
+		}
+	}
+	ok, _, retryAfter, reset := limiter.take("a")
+	if ok || retryAfter != 500*time.Millisecond || reset != 1500*time.Millisecond {
+		t.Errorf("expected no token before 500ms, got %t, %v, %v", ok, retryAfter, reset)
+	}
+	// every client has its own bucket
+	if ok, _, _, _ := limiter.take("b"); !ok {
+		t.Errorf("expected a token for another client")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if ok, remaining, _, _ := limiter.take("a"); !ok || remaining != 0 {
+		t.Errorf("expected a refilled token, got %t, %d", ok, remaining)
+	}
+
+	// the full buckets are swept
+	now = now.Add(sweepInterval)
+	limiter.take("c")
+	if len(limiter.buckets) != 1 {
+		t.Errorf("expected the full buckets to be swept, got %v", limiter.buckets)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	if err := router.SetTrustedProxies([]string{"10.0.0.0/8"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	router.Use(func(c *gin.Context) {
+		if apiKey := c.GetHeader("X-Test-Key"); apiKey != "" {
+			c.Set(APIKeyContextKey, apiKey)
+		}
+	}, rateLimit(map[string]*rateLimiter{
+		"/limited/:id": newRateLimiter(RateLimit{Rate: 1, Burst: 1}),
+	}))
+	router.GET("/limited/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/free", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(path, remoteAddr, forwardedFor, apiKey string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		if apiKey != "" {
+			req.Header.Set("X-Test-Key", apiKey)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/limited/1", "192.0.2.1:1234", "", "")
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "1" || w.Header().Get("RateLimit-Remaining") != "0" || w.Header().Get("RateLimit-Reset") != "1" {
+		t.Errorf("expected the first request to pass with the limit headers, got %d %v", w.Code, w.Header())
+	}
+	// the limit is per route, not per path
+	w = get("/limited/2", "192.0.2.1:1234", "", "")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected the second request to be limited, got %d %v", w.Code, w.Header())
+	}
+	if w := get("/free", "192.0.2.1:1234", "", ""); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("expected a route without limit to pass, got %d %v", w.Code, w.Header())
+	}
+
+	// X-Forwarded-For is only trusted from the proxies
+	if w := get("/limited/1", "192.0.2.2:1234", "198.51.100.1", ""); w.Code != http.StatusOK {
+		t.Errorf("expected another client to pass, got %d", w.Code)
+	}
+	if w := get("/limited/1", "192.0.2.2:1234", "198.51.100.2", ""); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected a spoofed X-Forwarded-For to be ignored, got %d", w.Code)
+	}
+	if w := get("/limited/1", "10.0.0.1:1234", "198.51.100.2", ""); w.Code != http.StatusOK {
+		t.Errorf("expected the client behind the proxy to pass, got %d", w.Code)
+	}
+
+	// an API key has its own limit, whatever its IP
+	if w := get("/limited/1", "192.0.2.1:1234", "", "key1"); w.Code != http.StatusOK {
+		t.Errorf("expected the API key to pass, got %d", w.Code)
+	}
+}
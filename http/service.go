@@ -1,63 +1,181 @@
 package http
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"slices"
 	"test-lbc/http/handlers"
+	"test-lbc/pkg"
+	fModels "test-lbc/pkg/models"
 	"test-lbc/prometheus"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Config is the configuration of the http server.
+type Config struct {
+	BindAddr           string
+	PrometheusBindAddr string
+	// DrainTimeout is how long the in-flight requests are waited for on shutdown
+	DrainTimeout time.Duration
+	// MaxBatchSize is the maximum number of requests of a batch
+	MaxBatchSize int
+	// RateLimits are the rate limits of every client by route, such as
+	// "/fizzbuzz/run"
+	RateLimits map[string]RateLimit
+	// AuthFailureLimit is the rate limit of the rejected API keys of every
+	// client IP, nil for none
+	AuthFailureLimit *RateLimit
+	// WSAllowedOrigins are the origins of the pages allowed to open a
+	// WebSocket besides the ones of the API host, "*" for any
+	WSAllowedOrigins []string
+	// TrustedProxies are the addresses or CIDRs of the proxies whose
+	// X-Forwarded-For header gives the client IP
+	TrustedProxies []string
+	// Keys are the API keys of the clients, nil to disable authentication
+	Keys pkg.KeyStore
+	// DBs are the databases whose connection pool stats are exposed in the
+	// prometheus metrics, by name
+	DBs map[string]*sql.DB
+}
+
 type Server struct {
-	db                 *sql.DB
-	bindAddr           string
-	prometheusBindAddr string
+	store  pkg.StatsStore
+	config Config
 
 	router *gin.Engine
+	// shutdown is closed on shutdown, closing the hijacked connections
+	shutdown chan struct{}
 }
 
-func New(db *sql.DB, bindAddr, prometheusBindAddr string) *Server {
+func New(store pkg.StatsStore, config Config) *Server {
 	return &Server{
-		db:                 db,
-		bindAddr:           bindAddr,
-		prometheusBindAddr: prometheusBindAddr,
+		store:  store,
+		config: config,
 	}
 }
 
-func (s *Server) Start() error {
-	log.Printf("start http server on port %s", s.bindAddr)
-
-	if s.prometheusBindAddr != "" {
-		go prometheus.Start(s.prometheusBindAddr)
-	}
-
+// Run serves the API, and the prometheus metrics when their address is set,
+// until ctx is done or a server fails. The servers are then shut down, waiting
+// for their in-flight requests up to the drain timeout.
+func (s *Server) Run(ctx context.Context) error {
 	gin.SetMode(gin.ReleaseMode)
 	s.router = gin.New()
+	s.shutdown = make(chan struct{})
+	if err := s.router.SetTrustedProxies(s.config.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %v", err)
+	}
+	limiters := map[string]*rateLimiter{}
+	for route, limit := range s.config.RateLimits {
+		limiters[route] = newRateLimiter(limit)
+	}
+	s.router.Use(requestID(), traces(), accessLog(), metrics(), recovery())
+	if s.config.Keys != nil {
+		var failures *rateLimiter
+		if s.config.AuthFailureLimit != nil {
+			failures = newRateLimiter(*s.config.AuthFailureLimit)
+		}
+		s.router.Use(authenticate(s.config.Keys, failures))
+	}
+	s.router.Use(rateLimit(limiters))
 	s.loadRoutes()
-	server := &http.Server{
-		Addr:           ":8080",
+	for route := range s.config.RateLimits {
+		if !slices.ContainsFunc(s.router.Routes(), func(info gin.RouteInfo) bool { return info.Path == route }) {
+			return fmt.Errorf("unknown rate limited route %q", route)
+		}
+	}
+	servers := []*http.Server{{
+		Addr:           s.config.BindAddr,
 		Handler:        s.router,
 		ReadTimeout:    10 * time.Second,
 		WriteTimeout:   10 * time.Second,
 		MaxHeaderBytes: 1 << 20,
+	}}
+	if s.config.PrometheusBindAddr != "" {
+		servers = append(servers, prometheus.NewServer(s.config.PrometheusBindAddr, s.config.DBs))
+	}
+
+	errc := make(chan error, len(servers))
+	for _, server := range servers {
+		slog.Info("start http server", "addr", server.Addr)
+		go func() {
+			if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				errc <- fmt.Errorf("failed to serve on %s: %v", server.Addr, err)
+			}
+		}()
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down http servers")
+	case err = <-errc:
 	}
 
-	server.ListenAndServe()
+	// the servers do not track the hijacked connections
+	close(s.shutdown)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.DrainTimeout)
+	defer cancel()
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("failed to drain http server", "addr", server.Addr, "error", err)
+			server.Close()
+		}
+	}
 
-	return nil
+	return err
 }
 
 func (s *Server) loadRoutes() {
+	// load probes routes
+	s.router.GET("/healthz", handlers.Healthz)
+	s.router.GET("/readyz", func(ctx *gin.Context) {
+		handlers.Readyz(ctx, s.store)
+	})
+
 	// load fizzBuzz routes
-	fbGroup := s.router.Group("/fizzbuzz")
+	fbGroup := s.router.Group("/fizzbuzz", s.authorize(fModels.ScopeRun)...)
 	fbGroup.Handle("POST", "/run", func(ctx *gin.Context) {
-		handlers.FizzBuzzRun(ctx, s.db)
+		handlers.FizzBuzzRun(ctx, s.store)
+	})
+	fbGroup.Handle("POST", "/batch", func(ctx *gin.Context) {
+		handlers.FizzBuzzBatch(ctx, s.store, s.config.MaxBatchSize)
 	})
-	fbStatsGroup := fbGroup.Group("/stats")
+	fbGroup.Handle("GET", "/stream", func(ctx *gin.Context) {
+		handlers.FizzBuzzStream(ctx, s.store)
+	})
+	fbGroup.Handle("GET", "/stream/ws", func(ctx *gin.Context) {
+		handlers.FizzBuzzStreamWS(ctx, s.store, s.config.WSAllowedOrigins, s.shutdown)
+	})
+	fbGroup.Handle("GET", "/at/:n", handlers.FizzBuzzAt)
+	fbGroup.Handle("GET", "/count", handlers.FizzBuzzCount)
+	fbStatsGroup := s.router.Group("/fizzbuzz/stats", s.authorize(fModels.ScopeStatsRead)...)
 	fbStatsGroup.Handle("GET", "/most-requested", func(ctx *gin.Context) {
-		handlers.FizzBuzzStats(ctx, s.db)
+		handlers.FizzBuzzStats(ctx, s.store)
+	})
+	fbStatsGroup.Handle("GET", "/top", func(ctx *gin.Context) {
+		handlers.FizzBuzzTop(ctx, s.store)
 	})
+	fbStatsGroup.Handle("GET", "/timeseries", func(ctx *gin.Context) {
+		handlers.FizzBuzzTimeSeries(ctx, s.store)
+	})
+	fbStatsAdminGroup := s.router.Group("/fizzbuzz/stats", s.authorize(fModels.ScopeStatsAdmin)...)
+	fbStatsAdminGroup.Handle("DELETE", "", func(ctx *gin.Context) {
+		handlers.FizzBuzzResetStats(ctx, s.store)
+	})
+}
+
+// authorize returns the middlewares restricting a route group to the API keys
+// granting the scope, none when authentication is disabled.
+func (s *Server) authorize(scope fModels.Scope) []gin.HandlerFunc {
+	if s.config.Keys == nil {
+		return nil
+	}
+
+	return []gin.HandlerFunc{requireScope(scope)}
 }
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"test-lbc/internal/testutil"
+	"test-lbc/pkg/store"
+	"testing"
+	"time"
+)
+
+func TestServer_Run(t *testing.T) {
+	t.Run("Shutdown", func(t *testing.T) {
+		addr := testutil.FreeAddr(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		errc := make(chan error, 1)
+		go func() {
+			errc <- New(store.NewMemory(), Config{BindAddr: addr, DrainTimeout: time.Second}).Run(ctx)
+		}()
+
+		// the server answers once it listens
+		var resp *http.Response
+		for range 100 {
+			var err error
+			if resp, err = http.Get("http://" + addr + "/fizzbuzz/at/15?int1=3&int2=5&str1=fizz&str2=buzz"); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if resp == nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected the server to answer, got %v", resp)
+		}
+		resp.Body.Close()
+
+		cancel()
+		select {
+		case err := <-errc:
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected the server to stop")
+		}
+	})
+
+	t.Run("Listen error", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer listener.Close()
+
+		// the address is already in use
+		if err := New(store.NewMemory(), Config{BindAddr: listener.Addr().String(), DrainTimeout: time.Second}).Run(context.Background()); err == nil {
+			t.Errorf("expected an error, got nil")
+		}
+	})
+
+	t.Run("Unknown rate limited route", func(t *testing.T) {
+		err := New(store.NewMemory(), Config{
+			BindAddr:   testutil.FreeAddr(t),
+			RateLimits: map[string]RateLimit{"/fizzbuzz/walk": {Rate: 1, Burst: 1}},
+		}).Run(context.Background())
+		if err == nil {
+			t.Errorf("expected an error, got nil")
+		}
+	})
+}
@@ -0,0 +1,20 @@
+// Package testutil holds the helpers shared by the tests of several packages.
+package testutil
+
+import (
+	"net"
+	"testing"
+)
+
+// FreeAddr returns a local address nothing listens on.
+func FreeAddr(t testing.TB) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
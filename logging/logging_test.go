@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, FormatJSON, "warn")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logger.Info("dropped")
+	logger.With("component", "test").WarnContext(WithRequestID(context.Background(), "abc"), "kept", "n", 1)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected a single record, got %q", buf.String())
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if record["msg"] != "kept" || record["level"] != "WARN" || record["request_id"] != "abc" || record["component"] != "test" || record["n"] != 1.0 {
+		t.Errorf("unexpected record %v", record)
+	}
+
+	buf.Reset()
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	logger.WarnContext(ctx, "traced")
+	if !strings.Contains(buf.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7"`) {
+		t.Errorf("expected the trace and span IDs, got %q", buf.String())
+	}
+
+	buf.Reset()
+	logger, _ = New(&buf, FormatText, "debug")
+	logger.Debug("text")
+	if !strings.Contains(buf.String(), "level=DEBUG msg=text") {
+		t.Errorf("expected a text record, got %q", buf.String())
+	}
+
+	if _, err := New(&buf, "xml", "info"); err == nil {
+		t.Errorf("expected an error on unknown format, got nil")
+	}
+	if _, err := New(&buf, FormatText, "verbose"); err == nil {
+		t.Errorf("expected an error on unknown level, got nil")
+	}
+}
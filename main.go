@@ -1,9 +1,15 @@
+//go:generate buf generate
+
 package main
 
 import (
+	"os"
 	"test-lbc/cmd"
 )
 
 func main() {
-	cmd.Execute()
+	// cobra already printed the error
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,250 @@
+package pkg
+
+import (
+	"math/bits"
+	"strings"
+	"test-lbc/pkg/models"
+)
+
+// Count returns how many times every word and plain numbers appear from start
+// to end (included). Its cost depends on the number of rules, not on the size
+// of the range.
+func Count(rules []models.Rule, mode models.Mode, start, end int) models.FizzBuzzCount {
+	if start > end {
+		start, end = end, start
+	}
+
+	var (
+		r = newCountRange(start, end)
+
+		count = models.FizzBuzzCount{
+			Total: int(uint64(end) - uint64(start) + 1),
+			Words: map[string]int{},
+		}
+		active []models.Rule
+	)
+
+	for _, rule := range rules {
+		if rule.Divisor != 0 {
+			active = append(active, rule)
+		}
+	}
+
+	if mode == models.ModeProduct {
+		countProduct(r, active, count.Words)
+	} else {
+		countExact(r, active, mode, count.Words)
+	}
+
+	count.Numbers = count.Total
+	for _, n := range count.Words {
+		count.Numbers -= n
+	}
+
+	return count
+}
+
+// countExact adds to words the numbers matched by exactly every subset of rules,
+// computed from the numbers divisible by the lcm of each subset with the
+// inclusion–exclusion principle.
+func countExact(r countRange, rules []models.Rule, mode models.Mode, words map[string]int) {
+	subsets := 1 << len(rules)
+
+	// exact[mask]: numbers divisible by every rule in mask
+	exact := make([]int, subsets)
+	lcms := make([]uint64, subsets)
+	lcms[0] = 1
+	for mask := 0; mask < subsets; mask++ {
+		if mask > 0 {
+			low := bits.TrailingZeros(uint(mask))
+			lcms[mask] = lcm(lcms[mask&(mask-1)], absUint(rules[low].Divisor))
+		}
+		exact[mask] = r.multiples(lcms[mask])
+	}
+
+	// exact[mask]: numbers divisible by every rule in mask and by no other rule
+	for i := range rules {
+		for mask := 0; mask < subsets; mask++ {
+			if mask&(1<<i) == 0 {
+				exact[mask] -= exact[mask|1<<i]
+			}
+		}
+	}
+
+	for mask := 1; mask < subsets; mask++ {
+		if exact[mask] == 0 {
+			continue
+		}
+
+		var matched []int
+		for i := range rules {
+			if mask&(1<<i) != 0 {
+				matched = append(matched, i)
+			}
+		}
+		switch mode {
+		case models.ModeFirst:
+			matched = matched[:1]
+		case models.ModeLast:
+			matched = matched[len(matched)-1:]
+		}
+
+		words[joinWords(rules, matched)] += exact[mask]
+	}
+}
+
+// countProduct adds to words the numbers represented by every subset of rules in
+// product mode: the numbers divisible by the product of the subset divisors but
+// not by the product of any subset having priority over it.
+func countProduct(r countRange, rules []models.Rule, words map[string]int) {
+	// previous holds the numbers divisible by the product of a previous subset,
+	// as a sum of multiples of lcms weighted by their inclusion–exclusion sign
+	previous := map[uint64]int{}
+
+	for size := len(rules); size > 0; size-- {
+		for _, subset := range combinations(len(rules), size) {
+			product := uint64(1)
+			for _, i := range subset {
+				product = r.clamp(mulUint(product, absUint(rules[i].Divisor)))
+			}
+
+			n := r.multiples(product)
+			next := map[uint64]int{product: 1}
+			for l, sign := range previous {
+				both := r.clamp(lcm(l, product))
+				n -= sign * r.multiples(both)
+				next[both] -= sign
+			}
+			for l, sign := range next {
+				if previous[l] += sign; previous[l] == 0 {
+					delete(previous, l)
+				}
+			}
+
+			if n > 0 {
+				words[joinWords(rules, subset)] += n
+			}
+		}
+	}
+}
+
+// combinations returns the subsets of size elements of [0, n) in lexical order.
+func combinations(n, size int) [][]int {
+	var (
+		result  [][]int
+		subset  = make([]int, size)
+		combine func(from, depth int)
+	)
+	combine = func(from, depth int) {
+		if depth == size {
+			result = append(result, append([]int(nil), subset...))
+			return
+		}
+		for i := from; i <= n-size+depth; i++ {
+			subset[depth] = i
+			combine(i+1, depth+1)
+		}
+	}
+	combine(0, 0)
+
+	return result
+}
+
+func joinWords(rules []models.Rule, matched []int) string {
+	var value strings.Builder
+	for _, i := range matched {
+		value.WriteString(rules[i].Word)
+	}
+
+	return value.String()
+}
+
+// countRange is a range of numbers split around 0, to count multiples with
+// unsigned arithmetic.
+type countRange struct {
+	// negative numbers from -negFrom to -negTo, positive ones from posFrom to posTo
+	negFrom, negTo, posFrom, posTo uint64
+	hasNeg, hasZero, hasPos        bool
+	// max is the greatest absolute value of the range
+	max uint64
+}
+
+func newCountRange(start, end int) countRange {
+	var r countRange
+	if start < 0 {
+		r.hasNeg = true
+		r.negFrom, r.negTo = absUint(start), 1
+		if end < 0 {
+			r.negTo = absUint(end)
+		}
+		r.max = r.negFrom
+	}
+	r.hasZero = start <= 0 && end >= 0
+	if end > 0 {
+		r.hasPos = true
+		r.posFrom, r.posTo = 1, uint64(end)
+		if start > 0 {
+			r.posFrom = uint64(start)
+		}
+		r.max = max(r.max, r.posTo)
+	}
+
+	return r
+}
+
+// multiples returns the number of multiples of l in the range, 0 standing for
+// a number too large to have any multiple but 0.
+func (r countRange) multiples(l uint64) int {
+	var n uint64
+	if r.hasZero {
+		n++
+	}
+	if l == 0 {
+		return int(n)
+	}
+	if r.hasNeg {
+		n += r.negFrom/l - (r.negTo-1)/l
+	}
+	if r.hasPos {
+		n += r.posTo/l - (r.posFrom-1)/l
+	}
+
+	return int(n)
+}
+
+// clamp maps every number too large to have any multiple but 0 in the range onto 0.
+func (r countRange) clamp(l uint64) uint64 {
+	if l > r.max {
+		return 0
+	}
+
+	return l
+}
+
+// lcm returns the least common multiple of a and b, or 0 when it overflows or
+// when one of them is 0.
+func lcm(a, b uint64) uint64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+
+	return mulUint(a/gcd(a, b), b)
+}
+
+// mulUint returns a*b, or 0 when it overflows or when one of them is 0.
+func mulUint(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi != 0 {
+		return 0
+	}
+
+	return lo
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"test-lbc/pkg/models"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	t.Run("Classic", func(t *testing.T) {
+		count := Count(models.ClassicRules(3, 5, "fizz", "buzz"), models.ModeConcat, 1, 100)
+
+		expected := models.FizzBuzzCount{
+			Total:   100,
+			Numbers: 53,
+			Words:   map[string]int{"fizz": 27, "buzz": 14, "fizzbuzz": 6},
+		}
+		if !reflect.DeepEqual(count, expected) {
+			t.Errorf("expected %v, got %v", expected, count)
+		}
+	})
+
+	t.Run("Huge range", func(t *testing.T) {
+		count := Count(models.ClassicRules(3, 5, "fizz", "buzz"), models.ModeConcat, 1, 1e18)
+
+		expected := models.FizzBuzzCount{
+			Total:   1e18,
+			Numbers: 533333333333333333,
+			Words:   map[string]int{"fizz": 266666666666666667, "buzz": 133333333333333334, "fizzbuzz": 66666666666666666},
+		}
+		if !reflect.DeepEqual(count, expected) {
+			t.Errorf("expected %v, got %v", expected, count)
+		}
+	})
+
+	t.Run("Largest divisors", func(t *testing.T) {
+		rules := []models.Rule{{Divisor: math.MinInt, Word: "min"}, {Divisor: math.MaxInt, Word: "max"}}
+		count := Count(rules, models.ModeProduct, math.MinInt, -2)
+
+		expected := models.FizzBuzzCount{
+			Total:   math.MaxInt,
+			Numbers: math.MaxInt - 2,
+			Words:   map[string]int{"min": 1, "max": 1},
+		}
+		if !reflect.DeepEqual(count, expected) {
+			t.Errorf("expected %v, got %v", expected, count)
+		}
+	})
+
+	// compare with the values of the sequence on random rules and ranges
+	random := rand.New(rand.NewSource(42))
+	words := []string{"a", "b", "c", "d", "e"}
+	for i := 0; i < 200; i++ {
+		var rules []models.Rule
+		for j := 0; j < 1+random.Intn(5); j++ {
+			rules = append(rules, models.Rule{Divisor: random.Intn(13) - 6, Word: words[random.Intn(len(words))]})
+		}
+		mode := models.Modes[random.Intn(len(models.Modes))]
+		start := random.Intn(400) - 200
+		end := start + random.Intn(400)
+
+		expected := models.FizzBuzzCount{Total: end - start + 1, Words: map[string]int{}}
+		for n := start; n <= end; n++ {
+			if len(Match(rules, mode, n)) == 0 {
+				expected.Numbers++
+				continue
+			}
+			expected.Words[Value(rules, mode, n)]++
+		}
+
+		if count := Count(rules, mode, end, start); !reflect.DeepEqual(count, expected) {
+			t.Fatalf("rules %v, mode %s, from %d to %d: expected %v, got %v", rules, mode, start, end, expected, count)
+		}
+	}
+}
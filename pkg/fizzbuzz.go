@@ -1,114 +1,295 @@
 package pkg
 
 import (
-	"database/sql"
-	"fmt"
-	"strconv"
+	"context"
+	"iter"
+	"math"
+	"sync/atomic"
 	"test-lbc/pkg/models"
+	"test-lbc/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracerName names the tracer of the service spans, looked up on every call
+// so that it follows the global provider once tracing is set up.
+const tracerName = "test-lbc/pkg"
+
+// MaxRules is the maximum number of rules accepted in a single request.
+const MaxRules = 10
+
+// StatsStore persists the number of hits of every request.
+type StatsStore interface {
+	// Inc adds a hit, received at the given time, to the request
+	Inc(ctx context.Context, params models.FizzBuzzParams, at time.Time) error
+	// IncBatch adds the hits of several requests at once
+	IncBatch(ctx context.Context, hits []models.FizzBuzzHits) error
+	// MostRequested returns the request having the most hits, the most recently
+	// hit first, or nil when there is none
+	MostRequested(ctx context.Context) (*models.FizzBuzzStats, error)
+	// Top returns the n requests having the most hits, by decreasing hits then
+	// by tieBreak
+	Top(ctx context.Context, n int, tieBreak models.TieBreak) ([]models.FizzBuzzStats, error)
+	// MostRequestedSince returns the request having the most hits in the buckets
+	// of the given granularity from the one holding since, or nil when there is none.
+	// The hits of the returned stats are the ones of this period.
+	MostRequestedSince(ctx context.Context, granularity models.Granularity, since time.Time) (*models.FizzBuzzStats, error)
+	// TimeSeries returns the non empty buckets of the given granularity of the
+	// request, from the one holding since, in chronological order
+	TimeSeries(ctx context.Context, params models.FizzBuzzParams, granularity models.Granularity, since time.Time) ([]models.FizzBuzzBucket, error)
+	// Reset deletes the hits of every request, and their history
+	Reset(ctx context.Context) error
+	// Ready checks the dependencies of the store
+	Ready(ctx context.Context) []models.HealthCheck
+	// Close saves what is not saved yet and releases the store
+	Close() error
+}
+
+// MaxTimeSeriesBuckets is the maximum number of buckets of a time series.
+const MaxTimeSeriesBuckets = 1000
+
 type FizzBuzzService struct {
-	db *sql.DB
+	store StatsStore
 }
 
-func NewFizzBuzzService(db *sql.DB) FizzBuzzService {
+func NewFizzBuzzService(store StatsStore) FizzBuzzService {
 	return FizzBuzzService{
-		db: db,
+		store: store,
 	}
 }
 
-func (s FizzBuzzService) Run(params models.FizzBuzzParams) ([]string, error) {
-	var (
-		result       = make([]string, params.Limit)
-		currentValue = 1
-	)
+// Run saves the request in stats and returns its lazily computed sequence.
+// The sequence is returned even when saving stats fails. Its span ends once
+// the sequence is ranged over.
+func (s FizzBuzzService) Run(ctx context.Context, params models.FizzBuzzParams) (iter.Seq2[int, string], error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "FizzBuzzService.Run", trace.WithAttributes(paramsAttributes(params)...))
 
-	if params.Int1 == 0 && params.Int2 == 0 {
-		for i := range result {
-			result[i] = strconv.Itoa(currentValue)
-			currentValue++
-		}
-		return result, nil
+	// when no rule can ever match, the sequence is only numbers and is not worth tracking
+	var err error
+	if hasActiveRule(params.Rules) {
+		err = s.store.Inc(ctx, params, time.Now())
 	}
 
-	int1TestValue, int2TestValue := params.Int1, params.Int2
-	if int1TestValue == 0 {
-		int1TestValue = params.Limit + 1
+	return traced(span, err, Sequence(params)), err
+}
+
+// Stream returns the lazily computed sequence from the index from. The
+// request is saved in stats only when the stream starts from the beginning,
+// a resumed stream being already counted. The sequence is returned even when
+// saving stats fails. Its span ends once the sequence is ranged over.
+func (s FizzBuzzService) Stream(ctx context.Context, params models.FizzBuzzParams, from int) (iter.Seq2[int, string], error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "FizzBuzzService.Stream", trace.WithAttributes(paramsAttributes(params)...))
+	span.SetAttributes(attribute.Int("fizzbuzz.from", from))
+
+	result := func(yield func(int, string) bool) {
+		if length, _ := Len(params.Start, params.End, params.Step); from >= length {
+			return
+		}
+		// the start of the rest can not overflow as it lies between start and
+		// end, even when from*step overflows
+		rest := params
+		rest.Start += from * params.Step
+		for i, value := range Sequence(rest) {
+			if !yield(from+i, value) {
+				return
+			}
+		}
 	}
-	if int2TestValue == 0 {
-		int2TestValue = params.Limit + 1
+
+	var err error
+	if from == 0 && hasActiveRule(params.Rules) {
+		err = s.store.Inc(ctx, params, time.Now())
 	}
 
-	for i := range result {
-		// NOTE:
-		// 		1. the commented code describes a behaviour where we replace the value with "str1str2" when multiples of int1*int2 are encountered
-		//		2. the running code describes a behaviour where we replace the value with "str1str2" when multiples of int1 and int2 are encountered
-		// both algorithm solves the original fizzbuzz BUT both have a different behaviour when int1 == int2
-		// the test expressed "all multiples of int1 and int2 are replaced by str1str2" so I went with the second algorithm and commented what seems to be more constant with the original fizz-buzz
+	return traced(span, err, result), err
+}
 
-		// switch {
-		// case currentValue%(int1TestValue*int2TestValue) == 0:
-		// 	result[i] = params.Str1 + params.Str2
-		// case currentValue%int1TestValue == 0:
-		// 	result[i] = params.Str1
-		// case currentValue%int2TestValue == 0:
-		// 	result[i] = params.Str2
-		// default:
-		// 	result[i] = strconv.Itoa(currentValue)
-		// }
+// RunBatch saves the requests in stats at once and returns their lazily
+// computed sequences, in the same order. The sequences are returned even
+// when saving stats fails. Its span ends once every sequence is ranged over,
+// or one of them is stopped early.
+func (s FizzBuzzService) RunBatch(ctx context.Context, params []models.FizzBuzzParams) ([]iter.Seq2[int, string], error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "FizzBuzzService.RunBatch", trace.WithAttributes(attribute.Int("fizzbuzz.batch_size", len(params))))
 
-		if currentValue%int1TestValue == 0 {
-			result[i] = params.Str1
+	now := time.Now()
+	results := make([]iter.Seq2[int, string], len(params))
+	var hits []models.FizzBuzzHits
+	for i, p := range params {
+		results[i] = Sequence(p)
+		if hasActiveRule(p.Rules) {
+			hits = append(hits, models.FizzBuzzHits{Params: p, Hits: 1, LastHitAt: now})
 		}
-		if currentValue%int2TestValue == 0 {
-			result[i] += params.Str2
+	}
+	var err error
+	if len(hits) > 0 {
+		err = s.store.IncBatch(ctx, hits)
+	}
+	if len(results) == 0 {
+		tracing.EndSpan(span, &err)
+		return results, err
+	}
+
+	// the batch is done once every sequence is, and as soon as the consumer
+	// gives up on one of them
+	var remaining atomic.Int64
+	remaining.Store(int64(len(results)))
+	for i, result := range results {
+		results[i] = func(yield func(int, string) bool) {
+			done := false
+			defer func() {
+				if !done || remaining.Add(-1) == 0 {
+					tracing.EndSpan(span, &err)
+				}
+			}()
+			for j, value := range result {
+				if !yield(j, value) {
+					return
+				}
+			}
+			done = true
 		}
-		if result[i] == "" {
-			result[i] = strconv.Itoa(currentValue)
+	}
+
+	return results, err
+}
+
+// traced returns seq ending span once ranged over, after its last value or
+// when the consumer stops early, as failed with err.
+func traced(span trace.Span, err error, seq iter.Seq2[int, string]) iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		defer tracing.EndSpan(span, &err)
+		for i, value := range seq {
+			if !yield(i, value) {
+				return
+			}
 		}
-		currentValue++
 	}
+}
 
-	return result, s.incStats(params)
+// paramsAttributes describes the request in the spans.
+func paramsAttributes(params models.FizzBuzzParams) []attribute.KeyValue {
+	length, _ := Len(params.Start, params.End, params.Step)
+	return []attribute.KeyValue{
+		attribute.Int("fizzbuzz.start", params.Start),
+		attribute.Int("fizzbuzz.end", params.End),
+		attribute.Int("fizzbuzz.step", params.Step),
+		attribute.Int("fizzbuzz.rules", len(params.Rules)),
+		attribute.String("fizzbuzz.mode", string(params.Mode)),
+		attribute.Int("fizzbuzz.values", length),
+	}
 }
 
-func (s *FizzBuzzService) incStats(params models.FizzBuzzParams) error {
-	_, err := s.db.Exec("INSERT INTO `stats` (`int1`,`int2`,`limit`,`str1`,`str2`,`hits`) VALUES (?,?,?,?,?,?) ON DUPLICATE KEY UPDATE `hits` = `hits`+1", params.Int1, params.Int2, params.Limit, params.Str1, params.Str2, 1)
-	if err != nil {
-		return fmt.Errorf("failed to save request: %v", err)
+// Sequence yields the index and the value of every number of the sequence,
+// computing them on demand so that its memory usage does not depend on its length.
+func Sequence(params models.FizzBuzzParams) iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		length, _ := Len(params.Start, params.End, params.Step)
+		n := params.Start
+		for i := 0; i < length; i++ {
+			if !yield(i, Value(params.Rules, params.Mode, n)) {
+				return
+			}
+			n += params.Step
+		}
+	}
+}
+
+// Len returns the number of values from start to end (included) by step.
+// It returns false when step does not lead from start to end, or when the
+// number of values does not fit in an int.
+func Len(start, end, step int) (int, bool) {
+	var distance uint64
+	switch {
+	case step > 0 && start <= end:
+		distance = uint64(end) - uint64(start)
+	case step < 0 && start >= end:
+		distance = uint64(start) - uint64(end)
+	default:
+		return 0, false
 	}
 
-	return nil
+	last := distance / absUint(step)
+	if last >= math.MaxInt {
+		return 0, false
+	}
+
+	return int(last) + 1, true
 }
 
-func (s FizzBuzzService) GetMostRequested() (*models.FizzBuzzStats, error) {
-	rows, err := s.db.Query("SELECT `int1`,`int2`,`limit`,`str1`,`str2`,`hits` FROM `stats` ORDER BY `hits` desc LIMIT 1")
+func (s FizzBuzzService) GetMostRequested(ctx context.Context) (*models.FizzBuzzStats, error) {
+	return s.store.MostRequested(ctx)
+}
+
+// GetTop returns the n most requested requests along with their rank.
+func (s FizzBuzzService) GetTop(ctx context.Context, n int, tieBreak models.TieBreak) ([]models.FizzBuzzRank, error) {
+	// the n+1th request tells whether the last one is tied
+	top, err := s.store.Top(ctx, n+1, tieBreak)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query most requested: %v", err)
-	}
-	defer rows.Close()
-
-	var mostRequested *models.FizzBuzzStats
-	for rows.Next() {
-		var (
-			int1, int2, limit, hits int
-			str1, str2              string
-		)
-		if err := rows.Scan(&int1, &int2, &limit, &str1, &str2, &hits); err != nil {
-			return nil, fmt.Errorf("failed to scan most requested: %v", err)
+		return nil, err
+	}
+
+	ranks := make([]models.FizzBuzzRank, 0, len(top))
+	for i, stats := range top {
+		rank := models.FizzBuzzRank{
+			Rank:          i + 1,
+			FizzBuzzStats: stats,
 		}
-		mostRequested = &models.FizzBuzzStats{
-			Int1:  int1,
-			Int2:  int2,
-			Limit: limit,
-			Str1:  str1,
-			Str2:  str2,
-			Hits:  hits,
+		if i > 0 && top[i-1].Hits == stats.Hits {
+			rank.Rank = ranks[i-1].Rank
+			rank.Tied = true
+			ranks[i-1].Tied = true
 		}
+		ranks = append(ranks, rank)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows.Err: %v", err)
+
+	return ranks[:min(n, len(ranks))], nil
+}
+
+// ResetStats deletes the stats of every request.
+func (s FizzBuzzService) ResetStats(ctx context.Context) error {
+	return s.store.Reset(ctx)
+}
+
+// Ready checks the dependencies of the service.
+func (s FizzBuzzService) Ready(ctx context.Context) []models.HealthCheck {
+	return s.store.Ready(ctx)
+}
+
+// GetMostRequestedSince returns the request having the most hits during the
+// last period, which is rounded to the granularity of the history it is read from.
+func (s FizzBuzzService) GetMostRequestedSince(ctx context.Context, period time.Duration) (*models.FizzBuzzStats, error) {
+	granularity := models.GranularityDay
+	switch {
+	case period <= 6*time.Hour:
+		granularity = models.GranularityMinute
+	case period <= 14*24*time.Hour:
+		granularity = models.GranularityHour
+	}
+
+	return s.store.MostRequestedSince(ctx, granularity, time.Now().Add(-period))
+}
+
+// GetTimeSeries returns the hits of the request per bucket of the given
+// granularity during the last period, empty buckets included.
+func (s FizzBuzzService) GetTimeSeries(ctx context.Context, params models.FizzBuzzParams, granularity models.Granularity, period time.Duration) ([]models.FizzBuzzBucket, error) {
+	now := time.Now()
+	buckets, err := s.store.TimeSeries(ctx, params, granularity, now.Add(-period))
+	if err != nil {
+		return nil, err
+	}
+
+	series := []models.FizzBuzzBucket{}
+	for bucket := granularity.Bucket(now.Add(-period)); !bucket.After(now); bucket = bucket.Add(granularity.Duration()) {
+		hits := 0
+		if len(buckets) > 0 && buckets[0].Bucket.Equal(bucket) {
+			hits = buckets[0].Hits
+			buckets = buckets[1:]
+		}
+		series = append(series, models.FizzBuzzBucket{Bucket: bucket, Hits: hits})
 	}
 
-	return mostRequested, nil
+	return series, nil
 }
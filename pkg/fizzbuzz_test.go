@@ -1,14 +1,23 @@
 package pkg
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"iter"
+	"math"
 	"reflect"
 	"regexp"
+	"slices"
+	"strings"
 	"test-lbc/pkg/models"
+	"test-lbc/pkg/store"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestFizzBuzzService_Run(t *testing.T) {
@@ -19,24 +28,51 @@ func TestFizzBuzzService_Run(t *testing.T) {
 	}{
 		{
 			name:     "Standard FizzBuzz",
-			params:   models.FizzBuzzParams{Int1: 3, Int2: 5, Limit: 15, Str1: "fizz", Str2: "buzz"},
+			params:   models.FizzBuzzParams{Start: 1, End: 15, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz")},
 			expected: []string{"1", "2", "fizz", "4", "buzz", "fizz", "7", "8", "fizz", "buzz", "11", "fizz", "13", "14", "fizzbuzz"},
 		},
 		{
 			name:     "Both int1 and int2 are zero",
-			params:   models.FizzBuzzParams{Int1: 0, Int2: 0, Limit: 3, Str1: "fizz", Str2: "buzz"},
+			params:   models.FizzBuzzParams{Start: 1, End: 3, Step: 1, Rules: models.ClassicRules(0, 0, "fizz", "buzz")},
 			expected: []string{"1", "2", "3"},
 		},
 		{
 			name:     "Only int1 is zero",
-			params:   models.FizzBuzzParams{Int1: 0, Int2: 3, Limit: 5, Str1: "fizz", Str2: "buzz"},
+			params:   models.FizzBuzzParams{Start: 1, End: 5, Step: 1, Rules: models.ClassicRules(0, 3, "fizz", "buzz")},
 			expected: []string{"1", "2", "buzz", "4", "5"},
 		},
 		{
 			name:     "Only int2 is zero",
-			params:   models.FizzBuzzParams{Int1: 3, Int2: 0, Limit: 5, Str1: "fizz", Str2: "buzz"},
+			params:   models.FizzBuzzParams{Start: 1, End: 5, Step: 1, Rules: models.ClassicRules(3, 0, "fizz", "buzz")},
 			expected: []string{"1", "2", "fizz", "4", "5"},
 		},
+		{
+			name:     "int1 equals int2",
+			params:   models.FizzBuzzParams{Start: 1, End: 3, Step: 1, Rules: models.ClassicRules(3, 3, "fizz", "buzz")},
+			expected: []string{"1", "2", "fizzbuzz"},
+		},
+		{
+			name:     "Window",
+			params:   models.FizzBuzzParams{Start: 1000, End: 1005, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz")},
+			expected: []string{"buzz", "1001", "fizz", "1003", "1004", "fizzbuzz"},
+		},
+		{
+			name:     "Countdown",
+			params:   models.FizzBuzzParams{Start: 5, End: -5, Step: -2, Rules: models.ClassicRules(3, 5, "fizz", "buzz")},
+			expected: []string{"buzz", "fizz", "1", "-1", "fizz", "buzz"},
+		},
+		{
+			name:     "int1 equals int2 with product mode",
+			params:   models.FizzBuzzParams{Start: 1, End: 9, Step: 1, Rules: models.ClassicRules(3, 3, "fizz", "buzz"), Mode: models.ModeProduct},
+			expected: []string{"1", "2", "fizz", "4", "5", "fizz", "7", "8", "fizzbuzz"},
+		},
+		{
+			name: "Four rules",
+			params: models.FizzBuzzParams{Start: 1, End: 22, Step: 1, Rules: []models.Rule{
+				{Divisor: 3, Word: "Fizz"}, {Divisor: 5, Word: "Buzz"}, {Divisor: 7, Word: "Bazz"}, {Divisor: 11, Word: "Bang"},
+			}},
+			expected: []string{"1", "2", "Fizz", "4", "Buzz", "Fizz", "Bazz", "8", "Fizz", "Buzz", "Bang", "Fizz", "13", "Bazz", "FizzBuzz", "16", "17", "Fizz", "19", "Buzz", "FizzBazz", "Bang"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -47,21 +83,25 @@ func TestFizzBuzzService_Run(t *testing.T) {
 			}
 			defer db.Close()
 
-			// We expect the stats query to be executed for all cases except when both ints are 0
-			if tc.params.Int1 != 0 || tc.params.Int2 != 0 {
+			// We expect the stats query to be executed for all cases except when every divisor is 0
+			if hasActiveRule(tc.params.Rules) {
+				mock.ExpectBegin()
 				mock.ExpectExec("INSERT INTO `stats`").
-					WithArgs(tc.params.Int1, tc.params.Int2, tc.params.Limit, tc.params.Str1, tc.params.Str2, 1).
+					WithArgs(tc.params.Key(), tc.params.Start, tc.params.End, tc.params.Step, sqlmock.AnyArg(), string(tc.params.Mode), 1, sqlmock.AnyArg()).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO `stats_history`").
+					WillReturnResult(sqlmock.NewResult(3, 3))
+				mock.ExpectCommit()
 			}
 
-			service := NewFizzBuzzService(db)
-			result, err := service.Run(tc.params)
+			service := NewFizzBuzzService(store.NewMySQL(db))
+			result, err := service.Run(context.Background(), tc.params)
 			if err != nil {
 				t.Errorf("error while running: %v", err)
 			}
 
-			if !reflect.DeepEqual(result, tc.expected) {
-				t.Errorf("expected %v, got %v", tc.expected, result)
+			if values := collect(result); !reflect.DeepEqual(values, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, values)
 			}
 
 			if err := mock.ExpectationsWereMet(); err != nil {
@@ -77,21 +117,23 @@ func TestFizzBuzzService_Run(t *testing.T) {
 		}
 		defer db.Close()
 
-		params := models.FizzBuzzParams{Int1: 3, Int2: 5, Limit: 3, Str1: "fizz", Str2: "buzz"}
+		params := models.FizzBuzzParams{Start: 1, End: 3, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz"), Mode: models.ModeConcat}
 		expectedResult := []string{"1", "2", "fizz"}
 
+		mock.ExpectBegin()
 		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `stats`")).
-			WithArgs(params.Int1, params.Int2, params.Limit, params.Str1, params.Str2, 1).
+			WithArgs(params.Key(), params.Start, params.End, params.Step, `[{"divisor":3,"word":"fizz"},{"divisor":5,"word":"buzz"}]`, "concat", 1, sqlmock.AnyArg()).
 			WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
 
-		service := NewFizzBuzzService(db)
-		result, err := service.Run(params)
+		service := NewFizzBuzzService(store.NewMySQL(db))
+		result, err := service.Run(context.Background(), params)
 		if err != nil {
 			t.Logf("error expected: %v", err)
 		}
 
-		if !reflect.DeepEqual(result, expectedResult) {
-			t.Errorf("expected result %v even with db error, got %v", expectedResult, result)
+		if values := collect(result); !reflect.DeepEqual(values, expectedResult) {
+			t.Errorf("expected result %v even with db error, got %v", expectedResult, values)
 		}
 
 		if err := mock.ExpectationsWereMet(); err != nil {
@@ -100,111 +142,336 @@ func TestFizzBuzzService_Run(t *testing.T) {
 	})
 }
 
-func TestFizzBuzzService_GetMostRequested(t *testing.T) {
-	query := regexp.QuoteMeta("SELECT `int1`,`int2`,`limit`,`str1`,`str2`,`hits` FROM `stats` ORDER BY `hits` desc LIMIT 1")
+func TestFizzBuzzService_RunBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
 
-	t.Run("Success", func(t *testing.T) {
-		db, mock, err := sqlmock.New()
-		if err != nil {
-			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-		}
-		defer db.Close()
-		service := NewFizzBuzzService(db)
+	params := []models.FizzBuzzParams{
+		{Start: 1, End: 3, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz"), Mode: models.ModeConcat},
+		{Start: 1, End: 2, Step: 1, Rules: models.ClassicRules(0, 0, "fizz", "buzz"), Mode: models.ModeConcat},
+		{Start: 5, End: 4, Step: -1, Rules: models.ClassicRules(2, 5, "fizz", "buzz"), Mode: models.ModeConcat},
+	}
+	expected := [][]string{{"1", "2", "fizz"}, {"1", "2"}, {"buzz", "fizz"}}
 
-		expectedStats := &models.FizzBuzzStats{
-			Int1: 3, Int2: 5, Limit: 100, Str1: "fizz", Str2: "buzz", Hits: 20,
-		}
+	// the requests having an active rule are saved in a single transaction,
+	// by params hash so that concurrent batches lock the rows in the same order
+	saved := []models.FizzBuzzParams{params[0], params[2]}
+	slices.SortFunc(saved, func(a, b models.FizzBuzzParams) int {
+		return strings.Compare(a.Key(), b.Key())
+	})
+	if saved[0].Key() != params[2].Key() {
+		t.Fatalf("expected the requests to be saved out of their order, got %v", saved)
+	}
+	mock.ExpectBegin()
+	for _, p := range saved {
+		mock.ExpectExec("INSERT INTO `stats`").
+			WithArgs(p.Key(), p.Start, p.End, p.Step, sqlmock.AnyArg(), string(p.Mode), 1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO `stats_history`").
+			WillReturnResult(sqlmock.NewResult(3, 3))
+	}
+	mock.ExpectCommit()
 
-		rows := sqlmock.NewRows([]string{"int1", "int2", "limit", "str1", "str2", "hits"}).
-			AddRow(expectedStats.Int1, expectedStats.Int2, expectedStats.Limit, expectedStats.Str1, expectedStats.Str2, expectedStats.Hits)
+	service := NewFizzBuzzService(store.NewMySQL(db))
+	results, err := service.RunBatch(context.Background(), params)
+	if err != nil {
+		t.Errorf("error while running: %v", err)
+	}
 
-		mock.ExpectQuery(query).WillReturnRows(rows)
+	var values [][]string
+	for _, result := range results {
+		values = append(values, collect(result))
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
 
-		stats, err := service.GetMostRequested()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
 
-		if err != nil {
-			t.Errorf("unexpected error: %v", err)
-		}
-		if !reflect.DeepEqual(stats, expectedStats) {
-			t.Errorf("expected stats %v, got %v", expectedStats, stats)
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
+	t.Run("No active rule", func(t *testing.T) {
+		results, err := NewFizzBuzzService(store.NewMySQL(db)).RunBatch(context.Background(), params[1:2])
+		if err != nil || len(results) != 1 {
+			t.Errorf("expected a result without saving stats, got %d, %v", len(results), err)
 		}
 	})
+}
 
-	t.Run("No rows found", func(t *testing.T) {
-		db, mock, err := sqlmock.New()
-		if err != nil {
-			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-		}
-		defer db.Close()
-		service := NewFizzBuzzService(db)
+func TestFizzBuzzService_Stream(t *testing.T) {
+	memory := store.NewMemory()
+	service := NewFizzBuzzService(memory)
+	params := models.FizzBuzzParams{Start: 10, End: 0, Step: -2, Rules: models.ClassicRules(3, 5, "fizz", "buzz"), Mode: models.ModeConcat}
 
-		rows := sqlmock.NewRows([]string{"int1", "int2", "limit", "str1", "str2", "hits"})
-		mock.ExpectQuery(query).WillReturnRows(rows)
+	testCases := []struct {
+		name          string
+		from          int
+		expectedIndex []int
+		expected      []string
+	}{
+		{name: "From the beginning", from: 0, expectedIndex: []int{0, 1, 2, 3, 4, 5}, expected: []string{"buzz", "8", "fizz", "4", "2", "fizzbuzz"}},
+		{name: "Resumed", from: 3, expectedIndex: []int{3, 4, 5}, expected: []string{"4", "2", "fizzbuzz"}},
+		{name: "Resumed after the end", from: 6},
+	}
 
-		stats, err := service.GetMostRequested()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := service.Stream(context.Background(), params, tc.from)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 
-		if err != nil {
-			t.Errorf("unexpected error: %v", err)
-		}
-		if stats != nil {
-			t.Errorf("expected nil stats, got %v", stats)
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
-		}
-	})
+			var (
+				indexes []int
+				values  []string
+			)
+			for i, value := range result {
+				indexes = append(indexes, i)
+				values = append(values, value)
+			}
+			if !reflect.DeepEqual(indexes, tc.expectedIndex) || !reflect.DeepEqual(values, tc.expected) {
+				t.Errorf("expected %v %v, got %v %v", tc.expectedIndex, tc.expected, indexes, values)
+			}
+		})
+	}
 
-	t.Run("Query error", func(t *testing.T) {
-		db, mock, err := sqlmock.New()
-		if err != nil {
-			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	// only the stream starting from the beginning is counted
+	stats, err := memory.MostRequested(context.Background())
+	if err != nil || stats == nil || stats.Hits != 1 {
+		t.Errorf("expected a single hit, got %v, %v", stats, err)
+	}
+}
+
+func TestSequence(t *testing.T) {
+	params := models.FizzBuzzParams{Start: 1, End: 1 << 62, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz")}
+
+	var values []string
+	for i, value := range Sequence(params) {
+		if i == 5 {
+			break
 		}
-		defer db.Close()
-		service := NewFizzBuzzService(db)
+		values = append(values, value)
+	}
 
-		dbErr := errors.New("query failed")
-		mock.ExpectQuery(query).WillReturnError(dbErr)
+	expected := []string{"1", "2", "fizz", "4", "buzz"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+}
 
-		stats, err := service.GetMostRequested()
+func TestLen(t *testing.T) {
+	testCases := []struct {
+		name             string
+		start, end, step int
+		expected         int
+		ok               bool
+	}{
+		{name: "Limit", start: 1, end: 100, step: 1, expected: 100, ok: true},
+		{name: "Single value", start: 7, end: 7, step: -3, expected: 1, ok: true},
+		{name: "Step does not reach end", start: 0, end: 10, step: 3, expected: 4, ok: true},
+		{name: "Descending", start: 10, end: -10, step: -5, expected: 5, ok: true},
+		{name: "Wrong direction", start: 10, end: 1, step: 1, ok: false},
+		{name: "Full int range", start: math.MinInt, end: math.MaxInt, step: 1, ok: false},
+		{name: "Full int range by 4", start: math.MinInt, end: math.MaxInt, step: 4, expected: 1 << 62, ok: true},
+	}
 
-		if stats != nil {
-			t.Errorf("expected nil stats on error, got %v", stats)
-		}
-		if err == nil {
-			t.Errorf("expected an error, but got nil")
-		} else if err.Error() != fmt.Sprintf("failed to query most requested: %s", dbErr.Error()) {
-			t.Errorf("unexpected error message: %s", err.Error())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			length, ok := Len(tc.start, tc.end, tc.step)
+			if ok != tc.ok || length != tc.expected {
+				t.Errorf("expected %d, %t, got %d, %t", tc.expected, tc.ok, length, ok)
+			}
+		})
+	}
+}
+
+func collect(seq iter.Seq2[int, string]) []string {
+	var values []string
+	for _, value := range seq {
+		values = append(values, value)
+	}
+
+	return values
+}
+
+func TestFizzBuzzService_GetMostRequested(t *testing.T) {
+	memory := store.NewMemory()
+	service := NewFizzBuzzService(memory)
+
+	stats, err := service.GetMostRequested(context.Background())
+	if err != nil || stats != nil {
+		t.Errorf("expected no stats, got %v, %v", stats, err)
+	}
+
+	params := models.FizzBuzzParams{Start: 1, End: 100, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz"), Mode: models.ModeConcat}
+	for range 2 {
+		if _, err := service.Run(context.Background(), params); err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
-	})
+	}
+	if _, err := service.Run(context.Background(), models.FizzBuzzParams{Start: 1, End: 10, Step: 1, Rules: params.Rules, Mode: models.ModeConcat}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
-	t.Run("Scan error", func(t *testing.T) {
-		db, mock, err := sqlmock.New()
-		if err != nil {
-			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	stats, err = service.GetMostRequested(context.Background())
+	if err != nil || stats == nil || stats.End != 100 || stats.Hits != 2 {
+		t.Errorf("expected 1 to 100 requested twice, got %v, %v", stats, err)
+	}
+}
+
+func TestFizzBuzzService_GetTop(t *testing.T) {
+	memory := store.NewMemory()
+	service := NewFizzBuzzService(memory)
+
+	rules := models.ClassicRules(3, 5, "fizz", "buzz")
+	for end, hits := range map[int]int{10: 3, 20: 2, 30: 2, 40: 2, 50: 1} {
+		for range hits {
+			if err := memory.Inc(context.Background(), models.FizzBuzzParams{Start: 1, End: end, Step: 1, Rules: rules, Mode: models.ModeConcat}, time.Unix(int64(end), 0)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 		}
-		defer db.Close()
-		service := NewFizzBuzzService(db)
+	}
 
-		rows := sqlmock.NewRows([]string{"int1", "int2", "limit", "str1", "str2", "hits"}).
-			AddRow(3, 5, 100, "fizz", "buzz", "not-an-integer") // Invalid type for hits
-		mock.ExpectQuery(query).WillReturnRows(rows)
+	testCases := []struct {
+		name     string
+		n        int
+		expected []models.FizzBuzzRank
+	}{
+		{
+			name: "Tied beyond n",
+			n:    3,
+			expected: []models.FizzBuzzRank{
+				{Rank: 1, FizzBuzzStats: models.FizzBuzzStats{End: 10, Hits: 3}},
+				{Rank: 2, Tied: true, FizzBuzzStats: models.FizzBuzzStats{End: 40, Hits: 2}},
+				{Rank: 2, Tied: true, FizzBuzzStats: models.FizzBuzzStats{End: 30, Hits: 2}},
+			},
+		},
+		{
+			name: "All",
+			n:    10,
+			expected: []models.FizzBuzzRank{
+				{Rank: 1, FizzBuzzStats: models.FizzBuzzStats{End: 10, Hits: 3}},
+				{Rank: 2, Tied: true, FizzBuzzStats: models.FizzBuzzStats{End: 40, Hits: 2}},
+				{Rank: 2, Tied: true, FizzBuzzStats: models.FizzBuzzStats{End: 30, Hits: 2}},
+				{Rank: 2, Tied: true, FizzBuzzStats: models.FizzBuzzStats{End: 20, Hits: 2}},
+				{Rank: 5, FizzBuzzStats: models.FizzBuzzStats{End: 50, Hits: 1}},
+			},
+		},
+	}
 
-		stats, err := service.GetMostRequested()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			top, err := service.GetTop(context.Background(), tc.n, models.TieBreakRecent)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(top) != len(tc.expected) {
+				t.Fatalf("expected %d ranks, got %d", len(tc.expected), len(top))
+			}
+			for i, rank := range top {
+				expected := tc.expected[i]
+				if rank.Rank != expected.Rank || rank.Tied != expected.Tied || rank.End != expected.End || rank.Hits != expected.Hits {
+					t.Errorf("expected %v at %d, got %v", expected, i, rank)
+				}
+			}
+		})
+	}
+}
 
-		if stats != nil {
-			t.Errorf("expected nil stats on scan error, got %v", stats)
+func TestFizzBuzzService_GetTimeSeries(t *testing.T) {
+	memory := store.NewMemory()
+	service := NewFizzBuzzService(memory)
+
+	params := models.FizzBuzzParams{Start: 1, End: 100, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz"), Mode: models.ModeConcat}
+	now := time.Now()
+	for _, at := range []time.Time{now, now, now.Add(-2 * time.Hour), now.Add(-48 * time.Hour)} {
+		if err := memory.Inc(context.Background(), params, at); err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
-		if err == nil {
-			t.Errorf("expected a scan error, but got nil")
+	}
+
+	series, err := service.GetTimeSeries(context.Background(), params, models.GranularityHour, 3*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var hits []int
+	for _, bucket := range series {
+		hits = append(hits, bucket.Hits)
+	}
+	// the bucket holding now-3h, the two next ones and the current one
+	if expected := []int{0, 1, 0, 2}; !reflect.DeepEqual(hits, expected) {
+		t.Errorf("expected hits %v, got %v", expected, hits)
+	}
+
+	stats, err := service.GetMostRequestedSince(context.Background(), 3*time.Hour)
+	if err != nil || stats == nil || stats.Hits != 3 {
+		t.Errorf("expected 3 hits during the last 3 hours, got %v, %v", stats, err)
+	}
+}
+
+func TestHistoryRetention(t *testing.T) {
+	// the longest time series, and the most requested periods read from the
+	// minute and hour buckets
+	for granularity, longest := range map[models.Granularity]time.Duration{
+		models.GranularityMinute: max(MaxTimeSeriesBuckets*time.Minute, 6*time.Hour),
+		models.GranularityHour:   max(MaxTimeSeriesBuckets*time.Hour, 14*24*time.Hour),
+	} {
+		if retention := granularity.Retention(); retention < longest {
+			t.Errorf("expected %s buckets kept at least %v, got %v", granularity, longest, retention)
 		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	if retention := models.GranularityDay.Retention(); retention != 0 {
+		t.Errorf("expected day buckets kept for ever, got %v", retention)
+	}
+}
+
+func TestFizzBuzzService_Spans(t *testing.T) {
+	spans := tracetest.NewInMemoryExporter()
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)))
+
+	service := NewFizzBuzzService(store.NewMemory())
+	params := models.FizzBuzzParams{Start: 1, End: 15, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz"), Mode: models.ModeConcat}
+	ended := func() []string {
+		var names []string
+		for _, span := range spans.GetSpans() {
+			names = append(names, span.Name)
 		}
-	})
+		return names
+	}
+
+	// the span covers the computation of the values
+	result, err := service.Run(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := ended(); len(names) != 0 {
+		t.Errorf("expected no span ended before the sequence is ranged over, got %v", names)
+	}
+	for range result {
+	}
+	if names := ended(); !reflect.DeepEqual(names, []string{"FizzBuzzService.Run"}) {
+		t.Errorf("expected the run span ended, got %v", names)
+	}
+
+	// a batch is done as soon as the consumer stops early
+	spans.Reset()
+	results, err := service.RunBatch(context.Background(), []models.FizzBuzzParams{params, params})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range results[0] {
+	}
+	if names := ended(); len(names) != 0 {
+		t.Errorf("expected no span ended before every sequence is ranged over, got %v", names)
+	}
+	for range results[1] {
+		break
+	}
+	if names := ended(); !reflect.DeepEqual(names, []string{"FizzBuzzService.RunBatch"}) {
+		t.Errorf("expected the batch span ended, got %v", names)
+	}
 }
@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"test-lbc/pkg/models"
+	"time"
+)
+
+// APIKeyPrefix starts every API key, which makes them easy to spot in a leak.
+const APIKeyPrefix = "fbk_"
+
+// KeyStore persists the API keys.
+type KeyStore interface {
+	// KeyByHash returns the key, revoked or not, having the hash, or nil when
+	// there is none
+	KeyByHash(hash string) (*models.APIKey, error)
+	// Keys returns every key, revoked or not, by creation date
+	Keys() ([]models.APIKey, error)
+	// CreateKey saves a new key
+	CreateKey(key models.APIKey) error
+	// RevokeKey revokes the key having the id at the given time, or fails when
+	// there is none
+	RevokeKey(id string, at time.Time) error
+}
+
+// NewAPIKey generates a key granting the scopes. It returns the key, to hand
+// to its owner, and its record, which only holds its hash.
+func NewAPIKey(name string, scopes []models.Scope) (string, models.APIKey, error) {
+	if len(scopes) == 0 {
+		return "", models.APIKey{}, errors.New("at least one scope is mandatory")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(models.Scopes, scope) {
+			return "", models.APIKey{}, fmt.Errorf("scope must be one of %v", models.Scopes)
+		}
+	}
+
+	id, secret := make([]byte, 8), make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", models.APIKey{}, fmt.Errorf("failed to generate api key: %v", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", models.APIKey{}, fmt.Errorf("failed to generate api key: %v", err)
+	}
+
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return key, models.APIKey{
+		ID:        hex.EncodeToString(id),
+		Name:      name,
+		Hash:      HashAPIKey(key),
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// HashAPIKey returns the hash of a key, under which it is stored. The keys
+// being random, a fast hash does not weaken them.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,36 @@
+package pkg
+
+import (
+	"strings"
+	"test-lbc/pkg/models"
+	"testing"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	key, record, err := NewAPIKey("alice", []models.Scope{models.ScopeRun, models.ScopeStatsAdmin})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(key, APIKeyPrefix) || len(record.ID) != 16 || record.Name != "alice" || record.CreatedAt.IsZero() {
+		t.Errorf("unexpected key %q, %v", key, record)
+	}
+	// only the hash of the key is kept
+	if record.Hash != HashAPIKey(key) || strings.Contains(record.Hash, key) {
+		t.Errorf("expected the hash of the key, got %q", record.Hash)
+	}
+	if !record.HasScope(models.ScopeRun) || !record.HasScope(models.ScopeStatsRead) {
+		t.Errorf("expected the run and stats:read scopes, got %v", record.Scopes)
+	}
+
+	other, _, err := NewAPIKey("alice", []models.Scope{models.ScopeRun})
+	if err != nil || other == key {
+		t.Errorf("expected another key, got %q, %v", other, err)
+	}
+
+	if _, _, err := NewAPIKey("alice", nil); err == nil {
+		t.Errorf("expected an error without scope, got nil")
+	}
+	if _, _, err := NewAPIKey("alice", []models.Scope{"stats:write"}); err == nil {
+		t.Errorf("expected an error on unknown scope, got nil")
+	}
+}
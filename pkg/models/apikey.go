@@ -0,0 +1,41 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Scope is a set of routes an API key grants access to.
+type Scope string
+
+const (
+	// ScopeRun grants the computation of sequences and values.
+	ScopeRun Scope = "run"
+	// ScopeStatsRead grants the reading of the stats.
+	ScopeStatsRead Scope = "stats:read"
+	// ScopeStatsAdmin grants the administration of the stats, which includes
+	// their reading.
+	ScopeStatsAdmin Scope = "stats:admin"
+)
+
+var Scopes = []Scope{ScopeRun, ScopeStatsRead, ScopeStatsAdmin}
+
+// APIKey is an API key of a client. The key itself is not kept, only its hash.
+type APIKey struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Hash is the hex encoded SHA-256 of the key
+	Hash      string     `json:"hash"`
+	Scopes    []Scope    `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope tells whether the key grants the scope.
+func (k APIKey) HasScope(scope Scope) bool {
+	if scope == ScopeStatsRead && slices.Contains(k.Scopes, ScopeStatsAdmin) {
+		return true
+	}
+
+	return slices.Contains(k.Scopes, scope)
+}
@@ -1,15 +1,158 @@
 package models
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Rule replaces every multiple of Divisor with Word.
+// A rule with a zero Divisor never matches.
+type Rule struct {
+	Divisor int    `json:"divisor"`
+	Word    string `json:"word"`
+}
+
+// Mode defines how the words of the rules matching a same number are combined.
+type Mode string
+
+const (
+	// ModeConcat concatenates the words of every matching rule.
+	ModeConcat Mode = "concat"
+	// ModeProduct replaces multiples of the product of several divisors by their
+	// concatenated words, the largest set of rules having priority (the original
+	// fizzbuzz: multiples of int1*int2 are str1str2).
+	ModeProduct Mode = "product"
+	// ModeFirst only keeps the word of the first matching rule.
+	ModeFirst Mode = "first"
+	// ModeLast only keeps the word of the last matching rule.
+	ModeLast Mode = "last"
+)
+
+var Modes = []Mode{ModeConcat, ModeProduct, ModeFirst, ModeLast}
+
+// FizzBuzzParams describes the sequence of the numbers from Start to End
+// (included) by Step.
 type FizzBuzzParams struct {
-	Int1, Int2, Limit int
-	Str1, Str2        string
+	Start, End, Step int
+	Rules            []Rule
+	Mode             Mode
+}
+
+// ClassicRules maps the historical int1/int2/str1/str2 parameters onto rules.
+func ClassicRules(int1, int2 int, str1, str2 string) []Rule {
+	return []Rule{
+		{Divisor: int1, Word: str1},
+		{Divisor: int2, Word: str2},
+	}
+}
+
+// Key returns a stable identifier of the params, used as stats primary key.
+func (p FizzBuzzParams) Key() string {
+	rules, _ := json.Marshal(p.Rules)
+	sum := sha256.Sum256([]byte(strconv.Itoa(p.Start) + "|" + strconv.Itoa(p.End) + "|" + strconv.Itoa(p.Step) + "|" + string(p.Mode) + "|" + string(rules)))
+	return hex.EncodeToString(sum[:])
 }
 
 type FizzBuzzStats struct {
-	Int1  int    `json:"int1"`
-	Int2  int    `json:"int2"`
-	Limit int    `json:"limit"`
-	Str1  string `json:"str1"`
-	Str2  string `json:"str2"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Step  int    `json:"step"`
+	Rules []Rule `json:"rules"`
+	Mode  Mode   `json:"mode"`
 	Hits  int    `json:"hits"`
+	// LastHitAt is the time of the last hit
+	LastHitAt time.Time `json:"last_hit_at"`
+}
+
+// TieBreak defines how requests having the same number of hits are ordered.
+type TieBreak string
+
+const (
+	// TieBreakRecent orders the most recently hit first.
+	TieBreakRecent TieBreak = "recent"
+	// TieBreakLexical orders by rules, mode, start, end and step.
+	TieBreakLexical TieBreak = "lexical"
+)
+
+var TieBreaks = []TieBreak{TieBreakRecent, TieBreakLexical}
+
+// FizzBuzzRank is the rank of a request by number of hits. Requests having
+// the same number of hits share the same rank, and the next rank is skipped.
+type FizzBuzzRank struct {
+	Rank int `json:"rank"`
+	// Tied is true when another request, ranked or not, has the same number of hits
+	Tied bool `json:"tied"`
+	FizzBuzzStats
+}
+
+// Granularity is the duration of the buckets of the stats history.
+type Granularity string
+
+const (
+	GranularityMinute Granularity = "minute"
+	GranularityHour   Granularity = "hour"
+	GranularityDay    Granularity = "day"
+)
+
+var Granularities = []Granularity{GranularityMinute, GranularityHour, GranularityDay}
+
+func (g Granularity) Duration() time.Duration {
+	switch g {
+	case GranularityMinute:
+		return time.Minute
+	case GranularityHour:
+		return time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// Bucket returns the start of the bucket holding t, in UTC.
+func (g Granularity) Bucket(t time.Time) time.Time {
+	return t.UTC().Truncate(g.Duration())
+}
+
+// Retention is how long the buckets of the granularity are kept before the
+// last hit, 0 for ever. The minute and hour buckets outlive the longest
+// period read from them, by a time series or the most requested request.
+func (g Granularity) Retention() time.Duration {
+	switch g {
+	case GranularityMinute:
+		return 24 * time.Hour
+	case GranularityHour:
+		return 60 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// FizzBuzzBucket is the number of hits of a request during a bucket of time.
+type FizzBuzzBucket struct {
+	Bucket time.Time `json:"bucket"`
+	Hits   int       `json:"hits"`
+}
+
+// FizzBuzzHits is a number of hits of a request, all during the minute of
+// the last one.
+type FizzBuzzHits struct {
+	Params    FizzBuzzParams
+	Hits      int
+	LastHitAt time.Time
+}
+
+// FizzBuzzValue is the value of a single number of a sequence.
+type FizzBuzzValue struct {
+	N       int    `json:"n"`
+	Value   string `json:"value"`
+	Matched []Rule `json:"matched"`
+}
+
+// FizzBuzzCount is the number of occurrences of every value of a sequence.
+type FizzBuzzCount struct {
+	Total   int            `json:"total"`
+	Numbers int            `json:"numbers"`
+	Words   map[string]int `json:"words"`
 }
@@ -0,0 +1,25 @@
+package models
+
+const (
+	HealthOK    = "ok"
+	HealthError = "error"
+	// HealthUnavailable is the status of the service when a check failed
+	HealthUnavailable = "unavailable"
+)
+
+// HealthCheck is the state of a dependency of the service.
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Detail describes the state, or the error
+	Detail string `json:"detail,omitempty"`
+}
+
+// NewHealthCheck returns an ok check with detail, or an error check when err is set.
+func NewHealthCheck(name, detail string, err error) HealthCheck {
+	if err != nil {
+		return HealthCheck{Name: name, Status: HealthError, Detail: err.Error()}
+	}
+
+	return HealthCheck{Name: name, Status: HealthOK, Detail: detail}
+}
@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"strconv"
+	"strings"
+	"test-lbc/pkg/models"
+)
+
+// At returns the value of n along with the rules it is made of, without
+// computing the sequence up to n.
+func At(rules []models.Rule, mode models.Mode, n int) models.FizzBuzzValue {
+	at := models.FizzBuzzValue{
+		N:       n,
+		Value:   Value(rules, mode, n),
+		Matched: []models.Rule{},
+	}
+	for _, i := range Match(rules, mode, n) {
+		at.Matched = append(at.Matched, rules[i])
+	}
+
+	return at
+}
+
+// Value returns the representation of n: the words of the rules selected by
+// Match, in rule order, or n itself when no rule matches.
+func Value(rules []models.Rule, mode models.Mode, n int) string {
+	matched := Match(rules, mode, n)
+	if len(matched) == 0 {
+		return strconv.Itoa(n)
+	}
+
+	var value strings.Builder
+	for _, i := range matched {
+		value.WriteString(rules[i].Word)
+	}
+
+	return value.String()
+}
+
+// Match returns the indexes of the rules whose words represent n, according to mode.
+func Match(rules []models.Rule, mode models.Mode, n int) []int {
+	var divides []int
+	for i, rule := range rules {
+		if rule.Divisor != 0 && n%rule.Divisor == 0 {
+			divides = append(divides, i)
+		}
+	}
+	if len(divides) == 0 {
+		return nil
+	}
+
+	switch mode {
+	case models.ModeFirst:
+		return divides[:1]
+	case models.ModeLast:
+		return divides[len(divides)-1:]
+	case models.ModeProduct:
+		return matchProduct(rules, divides, n)
+	default:
+		return divides
+	}
+}
+
+// matchProduct returns the largest subset of divides whose product of divisors
+// divides n, subsets of a same size being ordered by rule order.
+// e.g. with 3:fizz,3:buzz, 3 is "fizz" and 9 is "fizzbuzz".
+func matchProduct(rules []models.Rule, divides []int, n int) []int {
+	if n == 0 {
+		return divides
+	}
+
+	for size := len(divides); size > 1; size-- {
+		if subset := firstDividingSubset(rules, divides, size, n); subset != nil {
+			return subset
+		}
+	}
+
+	// every single rule in divides divides n
+	return divides[:1]
+}
+
+// firstDividingSubset returns the first subset of divides, of the given size
+// and in lexical order, whose product of divisors divides n.
+func firstDividingSubset(rules []models.Rule, divides []int, size, n int) []int {
+	subset := make([]int, size)
+	var walk func(from, depth, product int) bool
+	walk = func(from, depth, product int) bool {
+		if depth == size {
+			return n%product == 0
+		}
+		for i := from; i <= len(divides)-size+depth; i++ {
+			next, ok := mulDivisor(product, rules[divides[i]].Divisor, n)
+			if !ok {
+				continue
+			}
+			subset[depth] = divides[i]
+			if walk(i+1, depth+1, next) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if walk(0, 0, 1) {
+		return subset
+	}
+
+	return nil
+}
+
+// mulDivisor returns product*divisor, or false when the result can not divide n (n != 0).
+func mulDivisor(product, divisor, n int) (int, bool) {
+	next := product * divisor
+	if next/divisor != product || absUint(next) > absUint(n) {
+		return 0, false
+	}
+
+	return next, true
+}
+
+func absUint(n int) uint64 {
+	if n < 0 {
+		return -uint64(n)
+	}
+
+	return uint64(n)
+}
+
+func hasActiveRule(rules []models.Rule) bool {
+	for _, rule := range rules {
+		if rule.Divisor != 0 {
+			return true
+		}
+	}
+
+	return false
+}
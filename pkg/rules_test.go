@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"math"
+	"reflect"
+	"test-lbc/pkg/models"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	var (
+		classic = models.ClassicRules(3, 5, "fizz", "buzz")
+		same    = models.ClassicRules(3, 3, "fizz", "buzz")
+		four    = []models.Rule{{Divisor: 3, Word: "Fizz"}, {Divisor: 5, Word: "Buzz"}, {Divisor: 7, Word: "Bazz"}, {Divisor: 11, Word: "Bang"}}
+	)
+
+	testCases := []struct {
+		name     string
+		rules    []models.Rule
+		mode     models.Mode
+		n        int
+		expected string
+	}{
+		{name: "concat no match", rules: classic, mode: models.ModeConcat, n: 7, expected: "7"},
+		{name: "concat both", rules: classic, mode: models.ModeConcat, n: 15, expected: "fizzbuzz"},
+		{name: "concat same divisors", rules: same, mode: models.ModeConcat, n: 3, expected: "fizzbuzz"},
+		{name: "concat three rules", rules: four, mode: models.ModeConcat, n: 105, expected: "FizzBuzzBazz"},
+		{name: "product both", rules: classic, mode: models.ModeProduct, n: 15, expected: "fizzbuzz"},
+		{name: "product single", rules: classic, mode: models.ModeProduct, n: 5, expected: "buzz"},
+		{name: "product same divisors", rules: same, mode: models.ModeProduct, n: 3, expected: "fizz"},
+		{name: "product same divisors squared", rules: same, mode: models.ModeProduct, n: 9, expected: "fizzbuzz"},
+		{name: "product largest subset", rules: []models.Rule{{Divisor: 2, Word: "a"}, {Divisor: 2, Word: "b"}, {Divisor: 3, Word: "c"}}, mode: models.ModeProduct, n: 6, expected: "ac"},
+		{name: "product zero", rules: same, mode: models.ModeProduct, n: 0, expected: "fizzbuzz"},
+		{name: "product overflow", rules: models.ClassicRules(1<<40, 1<<40, "a", "b"), mode: models.ModeProduct, n: 1 << 41, expected: "a"},
+		{name: "first", rules: four, mode: models.ModeFirst, n: 35, expected: "Buzz"},
+		{name: "last", rules: four, mode: models.ModeLast, n: 35, expected: "Bazz"},
+		{name: "zero divisor", rules: models.ClassicRules(0, 5, "fizz", "buzz"), mode: models.ModeConcat, n: 10, expected: "buzz"},
+		{name: "negative", rules: classic, mode: models.ModeConcat, n: -9, expected: "fizz"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if value := Value(tc.rules, tc.mode, tc.n); value != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, value)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	rules := models.ClassicRules(3, 5, "fizz", "buzz")
+
+	if matched := Match(rules, models.ModeConcat, 15); !reflect.DeepEqual(matched, []int{0, 1}) {
+		t.Errorf("expected [0 1], got %v", matched)
+	}
+	if matched := Match(rules, models.ModeConcat, 7); matched != nil {
+		t.Errorf("expected no match, got %v", matched)
+	}
+}
+
+func TestAt(t *testing.T) {
+	rules := []models.Rule{{Divisor: 3, Word: "Fizz"}, {Divisor: 5, Word: "Buzz"}, {Divisor: 7, Word: "Bazz"}}
+
+	testCases := []struct {
+		name     string
+		mode     models.Mode
+		n        int
+		expected models.FizzBuzzValue
+	}{
+		{
+			name:     "No match",
+			mode:     models.ModeConcat,
+			n:        987654322,
+			expected: models.FizzBuzzValue{N: 987654322, Value: "987654322", Matched: []models.Rule{}},
+		},
+		{
+			name:     "Concat",
+			mode:     models.ModeConcat,
+			n:        987654339,
+			expected: models.FizzBuzzValue{N: 987654339, Value: "FizzBazz", Matched: []models.Rule{rules[0], rules[2]}},
+		},
+		{
+			name:     "Last",
+			mode:     models.ModeLast,
+			n:        math.MaxInt64 - 7,
+			expected: models.FizzBuzzValue{N: math.MaxInt64 - 7, Value: "Bazz", Matched: []models.Rule{rules[2]}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if at := At(rules, tc.mode, tc.n); !reflect.DeepEqual(at, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, at)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"test-lbc/pkg/models"
+	"test-lbc/prometheus"
+	"test-lbc/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// batchStore is a stats store able to save several hits at once.
+type batchStore interface {
+	IncBatch(ctx context.Context, hits []models.FizzBuzzHits) error
+	MostRequested(ctx context.Context) (*models.FizzBuzzStats, error)
+	Top(ctx context.Context, n int, tieBreak models.TieBreak) ([]models.FizzBuzzStats, error)
+	MostRequestedSince(ctx context.Context, granularity models.Granularity, since time.Time) (*models.FizzBuzzStats, error)
+	TimeSeries(ctx context.Context, params models.FizzBuzzParams, granularity models.Granularity, since time.Time) ([]models.FizzBuzzBucket, error)
+	Reset(ctx context.Context) error
+	Ready(ctx context.Context) []models.HealthCheck
+	Close() error
+}
+
+// ErrBatcherClosed is returned by Inc once the batcher is closed.
+var ErrBatcherClosed = errors.New("stats batcher closed")
+
+// Batcher is a write-behind stats store: it coalesces the hits of a request
+// during a same minute, and flushes them to the wrapped store in a single
+// batch every interval, or as soon as size increments are waiting.
+// Reads are delegated to the wrapped store, so they miss the hits not flushed yet.
+type Batcher struct {
+	batchStore
+
+	size  int
+	flush chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	mu      sync.Mutex
+	pending map[batchKey]*models.FizzBuzzHits
+	// waiting is the number of increments coalesced in pending
+	waiting int
+	closed  bool
+	// flushErr is the error of the last flush
+	flushErr error
+}
+
+// batchKey identifies the hits of a request during a minute.
+type batchKey struct {
+	params string
+	minute time.Time
+}
+
+// NewBatcher starts a batcher flushing to store. It must be closed to flush
+// the last hits.
+func NewBatcher(store batchStore, interval time.Duration, size int) *Batcher {
+	b := &Batcher{
+		batchStore: store,
+		size:       size,
+		flush:      make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		pending:    map[batchKey]*models.FizzBuzzHits{},
+	}
+
+	b.wg.Add(1)
+	go b.run(interval)
+
+	return b
+}
+
+// Inc queues the hit: it is saved by a later flush, in its own trace.
+func (b *Batcher) Inc(ctx context.Context, params models.FizzBuzzParams, at time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBatcherClosed
+	}
+
+	b.queue(models.FizzBuzzHits{Params: params, Hits: 1, LastHitAt: at}, at)
+
+	trace.SpanFromContext(ctx).AddEvent("stats queued", trace.WithAttributes(attribute.Int("stats.queue_depth", b.waiting)))
+	if b.waiting >= b.size {
+		// a flush already requested will take these hits too
+		select {
+		case b.flush <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Close stops the batcher after flushing the waiting hits, and closes the
+// wrapped store.
+func (b *Batcher) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	close(b.done)
+	b.wg.Wait()
+
+	err := b.Flush()
+	if closeErr := b.batchStore.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// queue coalesces the hits made during the minute of at into the pending
+// ones. b.mu must be held.
+func (b *Batcher) queue(h models.FizzBuzzHits, at time.Time) {
+	key := batchKey{params: h.Params.Key(), minute: models.GranularityMinute.Bucket(at)}
+	hits, ok := b.pending[key]
+	if !ok {
+		hits = &models.FizzBuzzHits{Params: h.Params}
+		b.pending[key] = hits
+	}
+	hits.Hits += h.Hits
+	if h.LastHitAt.After(hits.LastHitAt) {
+		hits.LastHitAt = h.LastHitAt
+	}
+
+	b.waiting += h.Hits
+	prometheus.SetStatsQueueDepth(b.waiting)
+}
+
+func (b *Batcher) run(interval time.Duration) {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+		case <-b.flush:
+		}
+		if err := b.Flush(); err != nil {
+			slog.Error("failed to flush stats", "error", err)
+		}
+	}
+}
+
+// Flush saves the waiting hits. When saving fails, they wait for the next
+// flush, merged into the hits queued meanwhile.
+func (b *Batcher) Flush() (err error) {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = map[batchKey]*models.FizzBuzzHits{}
+	b.waiting = 0
+	prometheus.SetStatsQueueDepth(0)
+	b.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	hits := make([]models.FizzBuzzHits, 0, len(pending))
+	for _, h := range pending {
+		hits = append(hits, *h)
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(context.Background(), "Batcher.Flush", trace.WithAttributes(attribute.Int("stats.batch_size", len(hits))))
+	defer tracing.EndSpan(span, &err)
+
+	start := time.Now()
+	err = b.IncBatch(ctx, hits)
+	b.mu.Lock()
+	b.flushErr = err
+	if err != nil {
+		for key, h := range pending {
+			b.queue(*h, key.minute)
+		}
+	}
+	b.mu.Unlock()
+	if err != nil {
+		prometheus.ObserveStatsFlush(time.Since(start), "error")
+		return err
+	}
+	prometheus.ObserveStatsFlush(time.Since(start), "success")
+
+	return nil
+}
+
+// Reset drops the waiting hits, and resets the wrapped store.
+func (b *Batcher) Reset(ctx context.Context) error {
+	b.mu.Lock()
+	b.pending = map[batchKey]*models.FizzBuzzHits{}
+	b.waiting = 0
+	prometheus.SetStatsQueueDepth(0)
+	b.mu.Unlock()
+
+	return b.batchStore.Reset(ctx)
+}
+
+// Ready checks the wrapped store, and that the stats are flushed: the last
+// flush must have succeeded, and the waiting increments must not pile up.
+func (b *Batcher) Ready(ctx context.Context) []models.HealthCheck {
+	b.mu.Lock()
+	backlog, flushErr := b.waiting, b.flushErr
+	b.mu.Unlock()
+
+	var err error
+	switch {
+	case flushErr != nil:
+		err = fmt.Errorf("last flush failed: %v", flushErr)
+	case backlog >= 2*b.size:
+		err = fmt.Errorf("%d waiting increments, flushes do not keep up", backlog)
+	}
+
+	return append(b.batchStore.Ready(ctx), models.NewHealthCheck("stats_writer", fmt.Sprintf("%d waiting increments", backlog), err))
+}
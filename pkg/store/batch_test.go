@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"test-lbc/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestBatcher(t *testing.T) {
+	var (
+		at      = time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+		classic = models.FizzBuzzParams{Start: 1, End: 100, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz"), Mode: models.ModeConcat}
+		other   = models.FizzBuzzParams{Start: 1, End: 10, Step: 1, Rules: []models.Rule{{Divisor: 8, Word: "eight"}}, Mode: models.ModeConcat}
+	)
+
+	t.Run("Flush on close", func(t *testing.T) {
+		memory := NewMemory()
+		batcher := NewBatcher(memory, time.Hour, 100)
+
+		for _, hit := range []struct {
+			params models.FizzBuzzParams
+			at     time.Time
+		}{
+			{classic, at},
+			{classic, at.Add(2 * time.Second)},
+			{classic, at.Add(time.Second)},
+			{other, at},
+			{classic, at.Add(time.Minute)},
+		} {
+			if err := batcher.Inc(context.Background(), hit.params, hit.at); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		if stats, err := memory.MostRequested(context.Background()); err != nil || stats != nil {
+			t.Errorf("expected no stats before flush, got %v, %v", stats, err)
+		}
+
+		if err := batcher.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		stats, err := batcher.MostRequested(context.Background())
+		if err != nil || stats == nil || stats.End != 100 || stats.Hits != 4 || !stats.LastHitAt.Equal(at.Add(time.Minute)) {
+			t.Errorf("expected 1 to 100 hit 4 times, got %v, %v", stats, err)
+		}
+		series, err := memory.TimeSeries(context.Background(), classic, models.GranularityMinute, at)
+		if expected := []models.FizzBuzzBucket{{Bucket: at, Hits: 3}, {Bucket: at.Add(time.Minute), Hits: 1}}; err != nil || !equalBuckets(series, expected) {
+			t.Errorf("expected series %v, got %v, %v", expected, series, err)
+		}
+
+		if err := batcher.Inc(context.Background(), classic, at); !errors.Is(err, ErrBatcherClosed) {
+			t.Errorf("expected %v once closed, got %v", ErrBatcherClosed, err)
+		}
+	})
+
+	t.Run("Flush on size", func(t *testing.T) {
+		memory := NewMemory()
+		// 3 increments of 2 requests
+		batcher := NewBatcher(memory, time.Hour, 3)
+		defer batcher.Close()
+
+		for _, params := range []models.FizzBuzzParams{classic, classic, other} {
+			if err := batcher.Inc(context.Background(), params, at); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		waitForStats(t, memory, 2)
+	})
+
+	t.Run("Flush on interval", func(t *testing.T) {
+		memory := NewMemory()
+		batcher := NewBatcher(memory, 10*time.Millisecond, 100)
+		defer batcher.Close()
+
+		if err := batcher.Inc(context.Background(), classic, at); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		waitForStats(t, memory, 1)
+	})
+
+	t.Run("Flush failed", func(t *testing.T) {
+		store := &flakyStore{Memory: NewMemory(), failures: 1}
+		batcher := NewBatcher(store, time.Hour, 100)
+
+		for _, params := range []models.FizzBuzzParams{classic, other} {
+			if err := batcher.Inc(context.Background(), params, at); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if err := batcher.Flush(); err == nil {
+			t.Fatalf("expected an error, got nil")
+		}
+		if err := batcher.Inc(context.Background(), classic, at.Add(time.Second)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := batcher.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		stats, err := store.MostRequested(context.Background())
+		if err != nil || stats == nil || stats.End != 100 || stats.Hits != 2 || !stats.LastHitAt.Equal(at.Add(time.Second)) {
+			t.Errorf("expected 1 to 100 hit 2 times, got %v, %v", stats, err)
+		}
+		if top, err := store.Top(context.Background(), 3, models.TieBreakRecent); err != nil || len(top) != 2 {
+			t.Errorf("expected 2 requests, got %v, %v", top, err)
+		}
+	})
+}
+
+// flakyStore fails to save hits the first failures times.
+type flakyStore struct {
+	*Memory
+	failures int
+}
+
+func (s *flakyStore) IncBatch(ctx context.Context, hits []models.FizzBuzzHits) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("db error")
+	}
+
+	return s.Memory.IncBatch(ctx, hits)
+}
+
+// failingStore fails to save hits.
+type failingStore struct {
+	*Memory
+}
+
+func (failingStore) IncBatch(ctx context.Context, hits []models.FizzBuzzHits) error {
+	return errors.New("db error")
+}
+
+func TestBatcher_Ready(t *testing.T) {
+	at := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	params := models.FizzBuzzParams{Start: 1, End: 100, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz"), Mode: models.ModeConcat}
+
+	batcher := NewBatcher(failingStore{NewMemory()}, time.Hour, 10)
+	defer batcher.Close()
+
+	expected := []models.HealthCheck{{Name: "stats_writer", Status: models.HealthOK, Detail: "0 waiting increments"}}
+	if checks := batcher.Ready(context.Background()); !reflect.DeepEqual(checks, expected) {
+		t.Errorf("expected %v, got %v", expected, checks)
+	}
+
+	if err := batcher.Inc(context.Background(), params, at); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := batcher.Flush(); err == nil {
+		t.Fatalf("expected an error, got nil")
+	}
+	if checks := batcher.Ready(context.Background()); len(checks) != 1 || checks[0].Status != models.HealthError {
+		t.Errorf("expected the stats writer to fail, got %v", checks)
+	}
+}
+
+// waitForStats waits for the memory store to hold n requests.
+func waitForStats(t *testing.T, memory *Memory, n int) {
+	t.Helper()
+
+	for range 100 {
+		if top, _ := memory.Top(context.Background(), n+1, models.TieBreakRecent); len(top) == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("expected %d requests to be flushed", n)
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"sync"
+	"test-lbc/pkg/models"
+	"time"
+)
+
+// historyPruning schedules the pruning of the expired buckets of the stats
+// history, at most once per minute of hits.
+type historyPruning struct {
+	mu sync.Mutex
+	// minute is the minute bucket of the last hit the history was pruned at
+	minute time.Time
+}
+
+// due tells whether the history must be pruned at the last of hits, and
+// returns its time.
+func (p *historyPruning) due(hits []models.FizzBuzzHits) (time.Time, bool) {
+	var at time.Time
+	for _, h := range hits {
+		if h.LastHitAt.After(at) {
+			at = h.LastHitAt
+		}
+	}
+	minute := models.GranularityMinute.Bucket(at)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !minute.After(p.minute) {
+		return at, false
+	}
+	p.minute = minute
+
+	return at, true
+}
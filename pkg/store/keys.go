@@ -0,0 +1,118 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"test-lbc/pkg/models"
+	"time"
+)
+
+// ErrKeyNotFound is returned when revoking a key that does not exist.
+var ErrKeyNotFound = errors.New("api key not found")
+
+// SQLKeys stores the API keys in the `api_keys` table of the stats database,
+// with the same queries on every dialect.
+type SQLKeys struct {
+	db *sql.DB
+}
+
+func NewSQLKeys(db *sql.DB) *SQLKeys {
+	return &SQLKeys{
+		db: db,
+	}
+}
+
+// DB returns the database of the store.
+func (s *SQLKeys) DB() *sql.DB {
+	return s.db
+}
+
+func (s *SQLKeys) KeyByHash(hash string) (*models.APIKey, error) {
+	keys, err := s.query("SELECT `id`,`name`,`key_hash`,`scopes`,`created_at`,`revoked_at` FROM `api_keys` WHERE `key_hash` = ?", hash)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+
+	return &keys[0], nil
+}
+
+func (s *SQLKeys) Keys() ([]models.APIKey, error) {
+	return s.query("SELECT `id`,`name`,`key_hash`,`scopes`,`created_at`,`revoked_at` FROM `api_keys` ORDER BY `created_at`, `id`")
+}
+
+func (s *SQLKeys) CreateKey(key models.APIKey) error {
+	_, err := s.db.Exec("INSERT INTO `api_keys` (`id`,`name`,`key_hash`,`scopes`,`created_at`) VALUES (?,?,?,?,?)",
+		key.ID, key.Name, key.Hash, joinScopes(key.Scopes), key.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save api key: %v", err)
+	}
+
+	return nil
+}
+
+// RevokeKey revokes the key, keeping the date of its first revocation.
+func (s *SQLKeys) RevokeKey(id string, at time.Time) error {
+	result, err := s.db.Exec("UPDATE `api_keys` SET `revoked_at` = ? WHERE `id` = ? AND `revoked_at` IS NULL", at.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	var n int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM `api_keys` WHERE `id` = ?", id).Scan(&n); err != nil {
+		return fmt.Errorf("failed to query api key: %v", err)
+	}
+	if n == 0 {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
+
+func (s *SQLKeys) query(query string, args ...any) ([]models.APIKey, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		var (
+			key       models.APIKey
+			scopes    string
+			revokedAt sql.NullTime
+		)
+		if err := rows.Scan(&key.ID, &key.Name, &key.Hash, &scopes, &key.CreatedAt, &revokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %v", err)
+		}
+		key.CreatedAt = key.CreatedAt.UTC()
+		for _, scope := range strings.Fields(scopes) {
+			key.Scopes = append(key.Scopes, models.Scope(scope))
+		}
+		if revokedAt.Valid {
+			at := revokedAt.Time.UTC()
+			key.RevokedAt = &at
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %v", err)
+	}
+
+	return keys, nil
+}
+
+// joinScopes returns the scopes as a space separated list.
+func joinScopes(scopes []models.Scope) string {
+	strs := make([]string, len(scopes))
+	for i, scope := range scopes {
+		strs[i] = string(scope)
+	}
+
+	return strings.Join(strs, " ")
+}
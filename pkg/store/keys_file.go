@@ -0,0 +1,161 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"test-lbc/pkg/models"
+	"time"
+)
+
+// FileKeys stores the API keys in a JSON file. The file is read again once
+// modified, so that the keys created or revoked by another process apply
+// without a restart.
+type FileKeys struct {
+	path string
+
+	mu   sync.Mutex
+	keys []models.APIKey
+	info fs.FileInfo
+}
+
+// OpenFileKeys opens the JSON file of API keys at path, which is created
+// along with the first key.
+func OpenFileKeys(path string) (*FileKeys, error) {
+	f := &FileKeys{
+		path: path,
+	}
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *FileKeys) KeyByHash(hash string) (*models.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(f.keys, func(key models.APIKey) bool {
+		return key.Hash == hash
+	})
+	if i < 0 {
+		return nil, nil
+	}
+	key := f.keys[i]
+
+	return &key, nil
+}
+
+func (f *FileKeys) Keys() ([]models.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+
+	return slices.Clone(f.keys), nil
+}
+
+func (f *FileKeys) CreateKey(key models.APIKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.reload(); err != nil {
+		return err
+	}
+
+	return f.write(append(slices.Clone(f.keys), key))
+}
+
+// RevokeKey revokes the key, keeping the date of its first revocation.
+func (f *FileKeys) RevokeKey(id string, at time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.reload(); err != nil {
+		return err
+	}
+	keys := slices.Clone(f.keys)
+	i := slices.IndexFunc(keys, func(key models.APIKey) bool {
+		return key.ID == id
+	})
+	if i < 0 {
+		return ErrKeyNotFound
+	}
+	if keys[i].RevokedAt != nil {
+		return nil
+	}
+	at = at.UTC()
+	keys[i].RevokedAt = &at
+
+	return f.write(keys)
+}
+
+// reload reads the file again when it was modified, a missing file holding
+// no key. Every write replacing the file, a modification changes its identity
+// even within the resolution of its modification time.
+func (f *FileKeys) reload() error {
+	info, err := os.Stat(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		f.keys, f.info = nil, nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read api keys: %v", err)
+	}
+	if f.info != nil && os.SameFile(info, f.info) && info.ModTime().Equal(f.info.ModTime()) && info.Size() == f.info.Size() {
+		return nil
+	}
+
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read api keys: %v", err)
+	}
+	var keys []models.APIKey
+	if err := json.Unmarshal(content, &keys); err != nil {
+		return fmt.Errorf("failed to decode api keys: %v", err)
+	}
+	f.keys, f.info = keys, info
+
+	return nil
+}
+
+// write replaces the file with keys, through a temporary file so that it is
+// never read half written.
+func (f *FileKeys) write(keys []models.APIKey) error {
+	content, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode api keys: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write api keys: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(content, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write api keys: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write api keys: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to write api keys: %v", err)
+	}
+
+	// the next read picks the identity of the new file
+	f.keys, f.info = keys, nil
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"test-lbc/pkg/models"
+	"testing"
+	"time"
+)
+
+type keyStore interface {
+	KeyByHash(hash string) (*models.APIKey, error)
+	Keys() ([]models.APIKey, error)
+	CreateKey(key models.APIKey) error
+	RevokeKey(id string, at time.Time) error
+}
+
+// testKeyStore runs the scenario every key store must pass.
+func testKeyStore(t *testing.T, s keyStore) {
+	keys, err := s.Keys()
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("expected no key, got %v, %v", keys, err)
+	}
+
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	reader := models.APIKey{ID: "0123456789abcdef", Name: "reader", Hash: "hash-reader", Scopes: []models.Scope{models.ScopeStatsRead}, CreatedAt: now}
+	runner := models.APIKey{ID: "fedcba9876543210", Name: "runner", Hash: "hash-runner", Scopes: []models.Scope{models.ScopeRun, models.ScopeStatsAdmin}, CreatedAt: now.Add(time.Second)}
+	for _, key := range []models.APIKey{reader, runner} {
+		if err := s.CreateKey(key); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	key, err := s.KeyByHash(runner.Hash)
+	if err != nil || key == nil || !reflect.DeepEqual(*key, runner) {
+		t.Errorf("expected key %v, got %v, %v", runner, key, err)
+	}
+	if key, err := s.KeyByHash("hash-unknown"); err != nil || key != nil {
+		t.Errorf("expected no key, got %v, %v", key, err)
+	}
+
+	if err := s.RevokeKey(reader.ID, now.Add(time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// revoking again keeps the first revocation
+	if err := s.RevokeKey(reader.ID, now.Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.RevokeKey("unknown", now); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+
+	revokedAt := now.Add(time.Minute)
+	reader.RevokedAt = &revokedAt
+	keys, err = s.Keys()
+	if expected := []models.APIKey{reader, runner}; err != nil || !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected keys %v, got %v, %v", expected, keys, err)
+	}
+}
+
+func TestSQLKeys(t *testing.T) {
+	db, err := OpenSQLiteDB(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+	migrator, err := NewMigrator(db, DialectSQLite)
+	if err == nil {
+		_, err = migrator.Up(context.Background(), 0)
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testKeyStore(t, NewSQLKeys(db))
+}
+
+func TestFileKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	keys, err := OpenFileKeys(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testKeyStore(t, keys)
+
+	t.Run("Modified by another process", func(t *testing.T) {
+		other, err := OpenFileKeys(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := other.RevokeKey("fedcba9876543210", time.Now()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		key, err := keys.KeyByHash("hash-runner")
+		if err != nil || key == nil || key.RevokedAt == nil {
+			t.Errorf("expected a revoked key, got %v, %v", key, err)
+		}
+	})
+
+	t.Run("Invalid file", func(t *testing.T) {
+		if _, err := OpenFileKeys(filepath.Join("testdata", "missing", "keys.json")); err != nil {
+			t.Errorf("expected a missing file to hold no key, got %v", err)
+		}
+		if _, err := OpenFileKeys(t.TempDir()); err == nil {
+			t.Errorf("expected an error on a directory, got nil")
+		}
+	})
+}
@@ -0,0 +1,227 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"test-lbc/pkg/models"
+	"time"
+)
+
+// Memory stores stats in memory, they are lost when the process stops.
+type Memory struct {
+	mu      sync.Mutex
+	stats   map[string]*memoryStats
+	pruning historyPruning
+}
+
+// memoryHistory holds the hits of a request by granularity and bucket.
+type memoryHistory map[models.Granularity]map[time.Time]int
+
+type memoryStats struct {
+	models.FizzBuzzStats
+	// rules is the JSON encoded rules, to order them as the SQL stores do
+	rules   string
+	history memoryHistory
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		stats: map[string]*memoryStats{},
+	}
+}
+
+func (m *Memory) Inc(ctx context.Context, params models.FizzBuzzParams, at time.Time) error {
+	return m.IncBatch(ctx, []models.FizzBuzzHits{{Params: params, Hits: 1, LastHitAt: at}})
+}
+
+func (m *Memory) IncBatch(ctx context.Context, hits []models.FizzBuzzHits) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, h := range hits {
+		key := h.Params.Key()
+		stats, ok := m.stats[key]
+		if !ok {
+			rules, err := json.Marshal(h.Params.Rules)
+			if err != nil {
+				return fmt.Errorf("failed to encode rules: %v", err)
+			}
+			stats = &memoryStats{
+				FizzBuzzStats: models.FizzBuzzStats{
+					Start: h.Params.Start,
+					End:   h.Params.End,
+					Step:  h.Params.Step,
+					Rules: slices.Clone(h.Params.Rules),
+					Mode:  h.Params.Mode,
+				},
+				rules:   string(rules),
+				history: memoryHistory{},
+			}
+			m.stats[key] = stats
+		}
+		stats.Hits += h.Hits
+		if h.LastHitAt.After(stats.LastHitAt) {
+			stats.LastHitAt = h.LastHitAt
+		}
+		for _, granularity := range models.Granularities {
+			if stats.history[granularity] == nil {
+				stats.history[granularity] = map[time.Time]int{}
+			}
+			stats.history[granularity][granularity.Bucket(h.LastHitAt)] += h.Hits
+		}
+	}
+
+	if at, ok := m.pruning.due(hits); ok {
+		m.pruneHistory(at)
+	}
+
+	return nil
+}
+
+// pruneHistory deletes the buckets of the history expired at the time of the
+// last hit.
+func (m *Memory) pruneHistory(at time.Time) {
+	for _, stats := range m.stats {
+		for granularity, buckets := range stats.history {
+			if granularity.Retention() == 0 {
+				continue
+			}
+			expired := granularity.Bucket(at.Add(-granularity.Retention()))
+			maps.DeleteFunc(buckets, func(bucket time.Time, _ int) bool {
+				return bucket.Before(expired)
+			})
+		}
+	}
+}
+
+func (m *Memory) Reset(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stats = map[string]*memoryStats{}
+
+	return nil
+}
+
+// Ready returns no check, as the memory store has no dependency.
+func (m *Memory) Ready(ctx context.Context) []models.HealthCheck {
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+func (m *Memory) MostRequested(ctx context.Context) (*models.FizzBuzzStats, error) {
+	top, _ := m.Top(ctx, 1, models.TieBreakRecent)
+	if len(top) == 0 {
+		return nil, nil
+	}
+
+	return &top[0], nil
+}
+
+func (m *Memory) Top(ctx context.Context, n int, tieBreak models.TieBreak) ([]models.FizzBuzzStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var compare func(a, b *memoryStats) int
+	switch tieBreak {
+	case models.TieBreakRecent:
+		compare = func(a, b *memoryStats) int {
+			return b.LastHitAt.Compare(a.LastHitAt)
+		}
+	case models.TieBreakLexical:
+		compare = func(a, b *memoryStats) int {
+			return cmp.Or(
+				cmp.Compare(a.rules, b.rules),
+				cmp.Compare(a.Mode, b.Mode),
+				cmp.Compare(a.Start, b.Start),
+				cmp.Compare(a.End, b.End),
+				cmp.Compare(a.Step, b.Step),
+			)
+		}
+	default:
+		return nil, fmt.Errorf("unknown tie-break %q", tieBreak)
+	}
+
+	keys := make([]string, 0, len(m.stats))
+	for key := range m.stats {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Or(
+			cmp.Compare(m.stats[b].Hits, m.stats[a].Hits),
+			compare(m.stats[a], m.stats[b]),
+			cmp.Compare(a, b),
+		)
+	})
+
+	var top []models.FizzBuzzStats
+	for _, key := range keys[:min(n, len(keys))] {
+		top = append(top, m.stats[key].FizzBuzzStats)
+	}
+
+	return top, nil
+}
+
+func (m *Memory) MostRequestedSince(ctx context.Context, granularity models.Granularity, since time.Time) (*models.FizzBuzzStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		mostRequested    *models.FizzBuzzStats
+		mostRequestedKey string
+	)
+	for key, stats := range m.stats {
+		hits := 0
+		for bucket, bucketHits := range stats.history[granularity] {
+			if !bucket.Before(granularity.Bucket(since)) {
+				hits += bucketHits
+			}
+		}
+		if hits == 0 {
+			continue
+		}
+
+		// same order as Top with the recent tie-break
+		if mostRequested == nil || cmp.Or(
+			cmp.Compare(mostRequested.Hits, hits),
+			mostRequested.LastHitAt.Compare(stats.LastHitAt),
+			cmp.Compare(key, mostRequestedKey),
+		) < 0 {
+			periodStats := stats.FizzBuzzStats
+			periodStats.Hits = hits
+			mostRequested, mostRequestedKey = &periodStats, key
+		}
+	}
+
+	return mostRequested, nil
+}
+
+func (m *Memory) TimeSeries(ctx context.Context, params models.FizzBuzzParams, granularity models.Granularity, since time.Time) ([]models.FizzBuzzBucket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.stats[params.Key()]
+	if !ok {
+		return nil, nil
+	}
+
+	var buckets []models.FizzBuzzBucket
+	for bucket, hits := range stats.history[granularity] {
+		if !bucket.Before(granularity.Bucket(since)) {
+			buckets = append(buckets, models.FizzBuzzBucket{Bucket: bucket, Hits: hits})
+		}
+	}
+	slices.SortFunc(buckets, func(a, b models.FizzBuzzBucket) int {
+		return a.Bucket.Compare(b.Bucket)
+	})
+
+	return buckets, nil
+}
@@ -0,0 +1,276 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect is the SQL dialect of a database, which selects its migrations.
+type Dialect string
+
+const (
+	DialectMySQL  Dialect = "mysql"
+	DialectSQLite Dialect = "sqlite"
+)
+
+// migrationsFS holds the migrations of every dialect, in
+// migrations/<dialect>/<version>_<name>.<up|down>.sql files.
+//
+//go:embed migrations
+var migrationsFS embed.FS
+
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// schemaMigrationsQueries create the table recording the applied migrations.
+var schemaMigrationsQueries = map[Dialect]string{
+	DialectMySQL:  "CREATE TABLE IF NOT EXISTS `schema_migrations` (`version` INT PRIMARY KEY, `name` VARCHAR(255), `checksum` CHAR(64), `applied_at` DATETIME(6)) ENGINE=InnoDB",
+	DialectSQLite: "CREATE TABLE IF NOT EXISTS `schema_migrations` (`version` INTEGER PRIMARY KEY, `name` TEXT, `checksum` TEXT, `applied_at` DATETIME)",
+}
+
+// schemaMigrationsExistQueries count the tables recording the applied
+// migrations, 0 or 1.
+var schemaMigrationsExistQueries = map[Dialect]string{
+	DialectMySQL:  "SELECT COUNT(*) FROM `information_schema`.`tables` WHERE `table_schema` = DATABASE() AND `table_name` = 'schema_migrations'",
+	DialectSQLite: "SELECT COUNT(*) FROM `sqlite_master` WHERE `type` = 'table' AND `name` = 'schema_migrations'",
+}
+
+// Migration is a versioned schema change.
+type Migration struct {
+	Version  int
+	Name     string
+	Up, Down string
+	// Checksum is the SHA-256 of Up, recorded when the migration is applied
+	// to detect a migration modified afterwards
+	Checksum string
+}
+
+// MigrationStatus tells whether a migration is applied.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies and reverts the migrations of a database.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+	}, nil
+}
+
+// loadMigrations reads the embedded migrations of the dialect, by version.
+func loadMigrations(dialect Dialect) ([]Migration, error) {
+	if _, ok := schemaMigrationsQueries[dialect]; !ok {
+		return nil, fmt.Errorf("unknown dialect %q", dialect)
+	}
+
+	dir := path.Join("migrations", string(dialect))
+	entries, err := fs.ReadDir(migrationsFS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		matches := migrationFileRegexp.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, _ := strconv.Atoi(matches[1])
+		content, err := fs.ReadFile(migrationsFS, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %v", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration %d is named both %q and %q", version, migration.Name, matches[2])
+		}
+		if matches[3] == "up" {
+			sum := sha256.Sum256(content)
+			migration.Up, migration.Checksum = string(content), hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d must have an up and a down file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return a.Version - b.Version
+	})
+
+	return migrations, nil
+}
+
+// Status returns every migration, telling whether it is applied. It fails
+// when an applied migration was modified or is unknown. It only reads the
+// database, so that the readiness probe can call it: every migration is
+// pending until the first one creates schema_migrations.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i].Migration = migration
+	}
+
+	var tables int
+	if err := m.db.QueryRowContext(ctx, schemaMigrationsExistQueries[m.dialect]).Scan(&tables); err != nil {
+		return nil, fmt.Errorf("failed to look for schema_migrations: %v", err)
+	}
+	if tables == 0 {
+		return statuses, nil
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT `version`,`checksum`,`applied_at` FROM `schema_migrations`")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			version   int
+			checksum  string
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &checksum, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %v", err)
+		}
+
+		i := slices.IndexFunc(statuses, func(status MigrationStatus) bool {
+			return status.Version == version
+		})
+		if i < 0 {
+			return nil, fmt.Errorf("applied migration %d is unknown, the schema is newer than this binary", version)
+		}
+		if statuses[i].Checksum != checksum {
+			return nil, fmt.Errorf("migration %d %s was modified after being applied", version, statuses[i].Name)
+		}
+		statuses[i].Applied, statuses[i].AppliedAt = true, appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %v", err)
+	}
+
+	return statuses, nil
+}
+
+// Up applies the pending migrations up to the target version (every one
+// when target is 0), and returns them.
+func (m *Migrator) Up(ctx context.Context, target int) ([]Migration, error) {
+	if _, err := m.db.ExecContext(ctx, schemaMigrationsQueries[m.dialect]); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, status := range statuses {
+		if status.Applied || (target > 0 && status.Version > target) {
+			continue
+		}
+		if err := m.apply(ctx, status.Migration, status.Up, "INSERT INTO `schema_migrations` (`version`,`name`,`checksum`,`applied_at`) VALUES (?,?,?,?)",
+			status.Version, status.Name, status.Checksum, time.Now().UTC()); err != nil {
+			return applied, err
+		}
+		applied = append(applied, status.Migration)
+	}
+
+	return applied, nil
+}
+
+// Down reverts the applied migrations above the target version (every one
+// when target is 0), the latest first, and returns them.
+func (m *Migrator) Down(ctx context.Context, target int) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for _, status := range slices.Backward(statuses) {
+		if !status.Applied || status.Version <= target {
+			continue
+		}
+		if err := m.apply(ctx, status.Migration, status.Down, "DELETE FROM `schema_migrations` WHERE `version` = ?", status.Version); err != nil {
+			return reverted, err
+		}
+		reverted = append(reverted, status.Migration)
+	}
+
+	return reverted, nil
+}
+
+// apply runs the script of the migration and records it with query, in a
+// single transaction. MySQL commits DDL statements implicitly though, so a
+// failing MySQL migration may be partially applied.
+func (m *Migrator) apply(ctx context.Context, migration Migration, script, query string, args ...any) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, statement := range statements(script) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to migrate %d %s: %v", migration.Version, migration.Name, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to record migration %d %s: %v", migration.Version, migration.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d %s: %v", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
+
+// statements splits a script into statements ending with a semicolon at the
+// end of a line, as the MySQL driver runs a single statement at once.
+func statements(script string) []string {
+	var statements []string
+	for _, statement := range strings.Split(script, ";\n") {
+		statement = strings.TrimSuffix(strings.TrimSpace(statement), ";")
+		if statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+
+	return statements
+}
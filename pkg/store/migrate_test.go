@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	var versions [][]string
+	for _, dialect := range []Dialect{DialectMySQL, DialectSQLite} {
+		migrations, err := loadMigrations(dialect)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", dialect, err)
+		}
+		var names []string
+		for _, migration := range migrations {
+			names = append(names, migration.Name)
+		}
+		versions = append(versions, names)
+	}
+
+	// every dialect has the same schema
+	if !reflect.DeepEqual(versions[0], versions[1]) {
+		t.Errorf("expected the same migrations, got %v and %v", versions[0], versions[1])
+	}
+
+	if _, err := loadMigrations("postgres"); err == nil {
+		t.Errorf("expected an error on unknown dialect, got nil")
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	db, err := OpenSQLiteDB(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := NewMigrator(db, DialectSQLite)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	versions := func(migrations []Migration, err error) []int {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var versions []int
+		for _, migration := range migrations {
+			versions = append(versions, migration.Version)
+		}
+		return versions
+	}
+	tableExists := func(table string) bool {
+		var n int
+		db.QueryRow("SELECT COUNT(*) FROM `sqlite_master` WHERE `type` = 'table' AND `name` = ?", table).Scan(&n)
+		return n == 1
+	}
+
+	// the status only reads the database
+	statuses, err := migrator.Status(context.Background())
+	if err != nil || len(statuses) != 3 || statuses[0].Applied || tableExists("schema_migrations") {
+		t.Errorf("expected every migration pending and no table created, got %v, %v", statuses, err)
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := migrator.Status(canceled); err == nil {
+		t.Errorf("expected an error on canceled context, got nil")
+	}
+
+	if applied := versions(migrator.Up(context.Background(), 1)); !reflect.DeepEqual(applied, []int{1}) {
+		t.Errorf("expected migration 1 applied, got %v", applied)
+	}
+	statuses, err = migrator.Status(context.Background())
+	if err != nil || len(statuses) != 3 || !statuses[0].Applied || statuses[1].Applied || statuses[2].Applied || statuses[0].AppliedAt.IsZero() {
+		t.Errorf("expected only migration 1 applied, got %v, %v", statuses, err)
+	}
+
+	if applied := versions(migrator.Up(context.Background(), 0)); !reflect.DeepEqual(applied, []int{2, 3}) {
+		t.Errorf("expected migrations 2 and 3 applied, got %v", applied)
+	}
+	if applied := versions(migrator.Up(context.Background(), 0)); len(applied) != 0 {
+		t.Errorf("expected no migration applied, got %v", applied)
+	}
+	if !tableExists("stats") || !tableExists("stats_history") || !tableExists("api_keys") {
+		t.Errorf("expected stats and api_keys tables to be created")
+	}
+
+	if reverted := versions(migrator.Down(context.Background(), 2)); !reflect.DeepEqual(reverted, []int{3}) {
+		t.Errorf("expected migration 3 reverted, got %v", reverted)
+	}
+	if !tableExists("stats_history") || tableExists("api_keys") {
+		t.Errorf("expected api_keys only to be dropped")
+	}
+	if reverted := versions(migrator.Down(context.Background(), 1)); !reflect.DeepEqual(reverted, []int{2}) {
+		t.Errorf("expected migration 2 reverted, got %v", reverted)
+	}
+	if !tableExists("stats") || tableExists("stats_history") {
+		t.Errorf("expected stats_history only to be dropped")
+	}
+	if reverted := versions(migrator.Down(context.Background(), 0)); !reflect.DeepEqual(reverted, []int{1}) {
+		t.Errorf("expected migration 1 reverted, got %v", reverted)
+	}
+	if tableExists("stats") {
+		t.Errorf("expected stats to be dropped")
+	}
+
+	if applied := versions(migrator.Up(context.Background(), 0)); !reflect.DeepEqual(applied, []int{1, 2, 3}) {
+		t.Errorf("expected migrations 1 to 3 applied, got %v", applied)
+	}
+
+	t.Run("Modified migration", func(t *testing.T) {
+		if _, err := db.Exec("UPDATE `schema_migrations` SET `checksum` = 'modified' WHERE `version` = 2"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer db.Exec("UPDATE `schema_migrations` SET `checksum` = ? WHERE `version` = 2", migrator.migrations[1].Checksum)
+
+		if _, err := migrator.Up(context.Background(), 0); err == nil || !strings.Contains(err.Error(), "modified") {
+			t.Errorf("expected a modified migration error, got %v", err)
+		}
+	})
+
+	t.Run("Unknown migration", func(t *testing.T) {
+		if _, err := db.Exec("INSERT INTO `schema_migrations` (`version`,`name`,`checksum`,`applied_at`) VALUES (99,'future','',?)", time.Now().UTC()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer db.Exec("DELETE FROM `schema_migrations` WHERE `version` = 99")
+
+		if _, err := migrator.Down(context.Background(), 0); err == nil || !strings.Contains(err.Error(), "unknown") {
+			t.Errorf("expected an unknown migration error, got %v", err)
+		}
+	})
+}
+
+func TestStatements(t *testing.T) {
+	script := "CREATE TABLE `a` (`b` INT);\n\nCREATE INDEX `c` ON `a` (`b`);\n"
+	expected := []string{"CREATE TABLE `a` (`b` INT)", "CREATE INDEX `c` ON `a` (`b`)"}
+	if got := statements(script); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,11 @@
+CREATE TABLE `stats` (
+    `params_hash` CHAR(64) COLLATE utf8mb4_bin,
+    `start` BIGINT,
+    `end` BIGINT,
+    `step` BIGINT,
+    `rules` TEXT COLLATE utf8mb4_unicode_ci,
+    `mode` VARCHAR(16) COLLATE utf8mb4_unicode_ci,
+    `hits` INT,
+    `last_hit_at` DATETIME(6),
+    PRIMARY KEY (`params_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `stats_history` (
+    `params_hash` CHAR(64) COLLATE utf8mb4_bin,
+    `granularity` VARCHAR(8) COLLATE utf8mb4_bin,
+    `bucket` DATETIME,
+    `hits` INT,
+    PRIMARY KEY (`params_hash`,`granularity`,`bucket`),
+    KEY `granularity_bucket` (`granularity`,`bucket`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `api_keys` (
+    `id` VARCHAR(16) COLLATE utf8mb4_bin,
+    `name` VARCHAR(255),
+    `key_hash` CHAR(64) COLLATE utf8mb4_bin,
+    `scopes` VARCHAR(255) COLLATE utf8mb4_bin,
+    `created_at` DATETIME(6),
+    `revoked_at` DATETIME(6) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `key_hash` (`key_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `stats` (
+    `params_hash` TEXT PRIMARY KEY,
+    `start` INTEGER,
+    `end` INTEGER,
+    `step` INTEGER,
+    `rules` TEXT,
+    `mode` TEXT,
+    `hits` INTEGER,
+    `last_hit_at` DATETIME
+);
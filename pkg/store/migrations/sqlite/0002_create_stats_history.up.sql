@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `stats_history` (
+    `params_hash` TEXT,
+    `granularity` TEXT,
+    `bucket` DATETIME,
+    `hits` INTEGER,
+    PRIMARY KEY (`params_hash`,`granularity`,`bucket`)
+);
+
+CREATE INDEX IF NOT EXISTS `stats_history_granularity_bucket` ON `stats_history` (`granularity`,`bucket`);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `api_keys` (
+    `id` TEXT PRIMARY KEY,
+    `name` TEXT,
+    `key_hash` TEXT UNIQUE,
+    `scopes` TEXT,
+    `created_at` DATETIME,
+    `revoked_at` DATETIME
+);
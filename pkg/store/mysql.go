@@ -0,0 +1,23 @@
+package store
+
+import (
+	"database/sql"
+)
+
+// MySQL stores stats in a MySQL database, whose schema is created by the
+// migrations/mysql migrations.
+type MySQL struct {
+	sqlStore
+}
+
+func NewMySQL(db *sql.DB) *MySQL {
+	return &MySQL{
+		sqlStore: sqlStore{
+			db:              db,
+			dialect:         DialectMySQL,
+			incQuery:        "INSERT INTO `stats` (`params_hash`,`start`,`end`,`step`,`rules`,`mode`,`hits`,`last_hit_at`) VALUES (?,?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE `hits` = `hits`+VALUES(`hits`), `last_hit_at` = GREATEST(`last_hit_at`, VALUES(`last_hit_at`))",
+			incHistoryQuery: "INSERT INTO `stats_history` (`params_hash`,`granularity`,`bucket`,`hits`) VALUES (?,?,?,?),(?,?,?,?),(?,?,?,?) ON DUPLICATE KEY UPDATE `hits` = `hits`+VALUES(`hits`)",
+			binaryRules:     "`rules` COLLATE utf8mb4_bin",
+		},
+	}
+}
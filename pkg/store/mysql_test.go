@@ -0,0 +1,246 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"test-lbc/pkg/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMySQL_Inc(t *testing.T) {
+	params := models.FizzBuzzParams{Start: 1, End: 3, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz"), Mode: models.ModeConcat}
+	at := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	query := regexp.QuoteMeta("INSERT INTO `stats` (`params_hash`,`start`,`end`,`step`,`rules`,`mode`,`hits`,`last_hit_at`) VALUES (?,?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE `hits` = `hits`+VALUES(`hits`), `last_hit_at` = GREATEST(`last_hit_at`, VALUES(`last_hit_at`))")
+	historyQuery := regexp.QuoteMeta("INSERT INTO `stats_history` (`params_hash`,`granularity`,`bucket`,`hits`) VALUES (?,?,?,?),(?,?,?,?),(?,?,?,?) ON DUPLICATE KEY UPDATE `hits` = `hits`+VALUES(`hits`)")
+	pruneQuery := regexp.QuoteMeta("DELETE FROM `stats_history` WHERE `granularity` = ? AND `bucket` < ?")
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(query).
+			WithArgs(params.Key(), params.Start, params.End, params.Step, `[{"divisor":3,"word":"fizz"},{"divisor":5,"word":"buzz"}]`, "concat", 1, at).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(historyQuery).
+			WithArgs(
+				params.Key(), "minute", at, 1,
+				params.Key(), "hour", at, 1,
+				params.Key(), "day", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), 1,
+			).
+			WillReturnResult(sqlmock.NewResult(3, 3))
+		mock.ExpectCommit()
+		mock.ExpectExec(pruneQuery).WithArgs("minute", at.Add(-24*time.Hour)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(pruneQuery).WithArgs("hour", at.Add(-60*24*time.Hour)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := NewMySQL(db).Inc(context.Background(), params, at); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Exec error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(query).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		if err := NewMySQL(db).Inc(context.Background(), params, at); err == nil {
+			t.Errorf("expected an error, but got nil")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("History error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(historyQuery).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		if err := NewMySQL(db).Inc(context.Background(), params, at); err == nil {
+			t.Errorf("expected an error, but got nil")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestMySQL_MostRequested(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT `start`,`end`,`step`,`rules`,`mode`,`hits`,`last_hit_at` FROM `stats` ORDER BY `hits` desc, `last_hit_at` desc, `params_hash` LIMIT ?")
+	columns := []string{"start", "end", "step", "rules", "mode", "hits", "last_hit_at"}
+	at := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+		store := NewMySQL(db)
+
+		expectedStats := &models.FizzBuzzStats{
+			Start: 1, End: 100, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz"), Mode: models.ModeProduct, Hits: 20, LastHitAt: at,
+		}
+
+		rows := sqlmock.NewRows(columns).
+			AddRow(expectedStats.Start, expectedStats.End, expectedStats.Step, `[{"divisor":3,"word":"fizz"},{"divisor":5,"word":"buzz"}]`, expectedStats.Mode, expectedStats.Hits, expectedStats.LastHitAt)
+
+		mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+
+		stats, err := store.MostRequested(context.Background())
+
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(stats, expectedStats) {
+			t.Errorf("expected stats %v, got %v", expectedStats, stats)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("No rows found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+		store := NewMySQL(db)
+
+		rows := sqlmock.NewRows(columns)
+		mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+
+		stats, err := store.MostRequested(context.Background())
+
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if stats != nil {
+			t.Errorf("expected nil stats, got %v", stats)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+		store := NewMySQL(db)
+
+		dbErr := errors.New("query failed")
+		mock.ExpectQuery(query).WithArgs(1).WillReturnError(dbErr)
+
+		stats, err := store.MostRequested(context.Background())
+
+		if stats != nil {
+			t.Errorf("expected nil stats on error, got %v", stats)
+		}
+		if err == nil {
+			t.Errorf("expected an error, but got nil")
+		} else if err.Error() != fmt.Sprintf("failed to query most requested: %s", dbErr.Error()) {
+			t.Errorf("unexpected error message: %s", err.Error())
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Scan error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+		store := NewMySQL(db)
+
+		rows := sqlmock.NewRows(columns).
+			AddRow(1, 100, 1, `[{"divisor":3,"word":"fizz"}]`, "concat", "not-an-integer", at) // Invalid type for hits
+		mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+
+		stats, err := store.MostRequested(context.Background())
+
+		if stats != nil {
+			t.Errorf("expected nil stats on scan error, got %v", stats)
+		}
+		if err == nil {
+			t.Errorf("expected a scan error, but got nil")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+	t.Run("Rules decode error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+		store := NewMySQL(db)
+
+		rows := sqlmock.NewRows(columns).
+			AddRow(1, 100, 1, "not-json", "concat", 3, at)
+		mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+
+		stats, err := store.MostRequested(context.Background())
+
+		if stats != nil {
+			t.Errorf("expected nil stats on decode error, got %v", stats)
+		}
+		if err == nil {
+			t.Errorf("expected a decode error, but got nil")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestMySQL_TopLexical(t *testing.T) {
+	// the rules are compared byte-wise, whatever the collation of the column
+	query := regexp.QuoteMeta("SELECT `start`,`end`,`step`,`rules`,`mode`,`hits`,`last_hit_at` FROM `stats` ORDER BY `hits` desc, `rules` COLLATE utf8mb4_bin, `mode`, `start`, `end`, `step`, `params_hash` LIMIT ?")
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(query).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"start", "end", "step", "rules", "mode", "hits", "last_hit_at"}))
+
+	if _, err := NewMySQL(db).Top(context.Background(), 3, models.TieBreakLexical); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,370 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"test-lbc/pkg/models"
+	"test-lbc/prometheus"
+	"test-lbc/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName names the tracer of the store spans.
+const tracerName = "test-lbc/pkg/store"
+
+// sqlStore holds the stats queries shared by the SQL backends, which only
+// differ by their upsert syntax.
+type sqlStore struct {
+	db      *sql.DB
+	dialect Dialect
+	// incQuery inserts a request with some hits, or adds them to its hits
+	incQuery string
+	// incHistoryQuery inserts the minute, hour and day buckets of a request
+	// with some hits, or adds them to their hits
+	incHistoryQuery string
+	// binaryRules is the rules column compared byte-wise, as the memory store
+	// compares them
+	binaryRules string
+
+	pruning historyPruning
+	// tracerProvider records the spans of the queries, the global one when nil
+	tracerProvider trace.TracerProvider
+}
+
+// tracer returns the tracer of the queries, looked up on every call so that
+// it follows the global provider once tracing is set up.
+func (s *sqlStore) tracer() trace.Tracer {
+	if s.tracerProvider != nil {
+		return s.tracerProvider.Tracer(tracerName)
+	}
+
+	return otel.Tracer(tracerName)
+}
+
+func (s *sqlStore) Inc(ctx context.Context, params models.FizzBuzzParams, at time.Time) error {
+	return s.IncBatch(ctx, []models.FizzBuzzHits{{Params: params, Hits: 1, LastHitAt: at}})
+}
+
+// IncBatch saves the hits in a single transaction.
+func (s *sqlStore) IncBatch(ctx context.Context, hits []models.FizzBuzzHits) (err error) {
+	// the hits are saved even when the client went away meanwhile
+	ctx, end := s.startQuery(context.WithoutCancel(ctx), "inc_batch", "")
+	defer end(&err)
+
+	// rows are always locked in the same order, so that concurrent batches
+	// cannot deadlock
+	keyed := make([]keyedHits, len(hits))
+	for i, h := range hits {
+		keyed[i] = keyedHits{key: h.Params.Key(), FizzBuzzHits: h}
+	}
+	slices.SortFunc(keyed, func(a, b keyedHits) int {
+		return cmp.Or(cmp.Compare(a.key, b.key), a.LastHitAt.Compare(b.LastHitAt))
+	})
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, h := range keyed {
+		rules, err := json.Marshal(h.Params.Rules)
+		if err != nil {
+			return fmt.Errorf("failed to encode rules: %v", err)
+		}
+
+		key := h.key
+		err = s.exec(ctx, tx, "stats", s.incQuery, key, h.Params.Start, h.Params.End, h.Params.Step, string(rules), string(h.Params.Mode), h.Hits, h.LastHitAt.UTC())
+		if err != nil {
+			return fmt.Errorf("failed to save request: %v", err)
+		}
+
+		var args []any
+		for _, granularity := range models.Granularities {
+			args = append(args, key, string(granularity), granularity.Bucket(h.LastHitAt), h.Hits)
+		}
+		if err := s.exec(ctx, tx, "stats_history", s.incHistoryQuery, args...); err != nil {
+			return fmt.Errorf("failed to save request history: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit request: %v", err)
+	}
+
+	// the hits are saved even when pruning fails, it is retried a minute later
+	if at, ok := s.pruning.due(hits); ok {
+		if err := s.pruneHistory(ctx, at); err != nil {
+			slog.WarnContext(ctx, "failed to prune stats history", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// pruneHistory deletes the buckets of the history expired at the time of the
+// last hit.
+func (s *sqlStore) pruneHistory(ctx context.Context, at time.Time) (err error) {
+	statement := "DELETE FROM `stats_history` WHERE `granularity` = ? AND `bucket` < ?"
+	ctx, end := s.startQuery(ctx, "prune_history", statement)
+	defer end(&err)
+
+	for _, granularity := range models.Granularities {
+		if granularity.Retention() == 0 {
+			continue
+		}
+		if _, err = s.db.ExecContext(ctx, statement, string(granularity), granularity.Bucket(at.Add(-granularity.Retention()))); err != nil {
+			return fmt.Errorf("failed to prune %s history: %v", granularity, err)
+		}
+	}
+
+	return nil
+}
+
+// Reset deletes the stats and their history in a single transaction.
+func (s *sqlStore) Reset(ctx context.Context) (err error) {
+	ctx, end := s.startQuery(ctx, "reset", "")
+	defer end(&err)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"stats_history", "stats"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM `"+table+"`"); err != nil {
+			return fmt.Errorf("failed to reset %s: %v", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reset: %v", err)
+	}
+
+	return nil
+}
+
+// keyedHits are hits along with the params hash of their request.
+type keyedHits struct {
+	key string
+	models.FizzBuzzHits
+}
+
+// Ready checks the database answers and its schema is up to date.
+func (s *sqlStore) Ready(ctx context.Context) []models.HealthCheck {
+	if err := s.db.PingContext(ctx); err != nil {
+		return []models.HealthCheck{models.NewHealthCheck("database", "", err)}
+	}
+
+	return []models.HealthCheck{
+		models.NewHealthCheck("database", string(s.dialect), nil),
+		s.schemaCheck(ctx),
+	}
+}
+
+func (s *sqlStore) schemaCheck(ctx context.Context) models.HealthCheck {
+	migrator, err := NewMigrator(s.db, s.dialect)
+	if err != nil {
+		return models.NewHealthCheck("schema", "", err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return models.NewHealthCheck("schema", "", err)
+	}
+
+	version, pending := 0, 0
+	for _, status := range statuses {
+		if status.Applied {
+			version = status.Version
+		} else {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return models.NewHealthCheck("schema", "", fmt.Errorf("%d pending migrations", pending))
+	}
+
+	return models.NewHealthCheck("schema", fmt.Sprintf("version %d", version), nil)
+}
+
+// DB returns the database of the store.
+func (s *sqlStore) DB() *sql.DB {
+	return s.db
+}
+
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqlStore) MostRequested(ctx context.Context) (_ *models.FizzBuzzStats, err error) {
+	top, err := s.top(ctx, "most_requested", 1, models.TieBreakRecent)
+	if err != nil {
+		return nil, err
+	}
+	if len(top) == 0 {
+		return nil, nil
+	}
+
+	return &top[0], nil
+}
+
+func (s *sqlStore) Top(ctx context.Context, n int, tieBreak models.TieBreak) ([]models.FizzBuzzStats, error) {
+	return s.top(ctx, "top", n, tieBreak)
+}
+
+// top queries the n requests having the most hits, as the query of the
+// metrics and traces.
+func (s *sqlStore) top(ctx context.Context, query string, n int, tieBreak models.TieBreak) (_ []models.FizzBuzzStats, err error) {
+	// the params hash makes the order deterministic
+	var order string
+	switch tieBreak {
+	case models.TieBreakRecent:
+		order = "`hits` desc, `last_hit_at` desc, `params_hash`"
+	case models.TieBreakLexical:
+		order = "`hits` desc, " + s.binaryRules + ", `mode`, `start`, `end`, `step`, `params_hash`"
+	default:
+		return nil, fmt.Errorf("unknown tie-break %q", tieBreak)
+	}
+
+	statement := "SELECT `start`,`end`,`step`,`rules`,`mode`,`hits`,`last_hit_at` FROM `stats` ORDER BY " + order + " LIMIT ?"
+	ctx, end := s.startQuery(ctx, query, statement)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx, statement, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query most requested: %v", err)
+	}
+
+	return scanStats(rows)
+}
+
+func (s *sqlStore) MostRequestedSince(ctx context.Context, granularity models.Granularity, since time.Time) (_ *models.FizzBuzzStats, err error) {
+	statement := "SELECT s.`start`,s.`end`,s.`step`,s.`rules`,s.`mode`,SUM(h.`hits`) AS `period_hits`,s.`last_hit_at` " +
+		"FROM `stats_history` h JOIN `stats` s ON s.`params_hash` = h.`params_hash` " +
+		"WHERE h.`granularity` = ? AND h.`bucket` >= ? " +
+		"GROUP BY s.`params_hash` ORDER BY `period_hits` desc, s.`last_hit_at` desc, s.`params_hash` LIMIT 1"
+	ctx, end := s.startQuery(ctx, "most_requested_since", statement)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx, statement, string(granularity), granularity.Bucket(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query most requested: %v", err)
+	}
+
+	top, err := scanStats(rows)
+	if err != nil || len(top) == 0 {
+		return nil, err
+	}
+
+	return &top[0], nil
+}
+
+func (s *sqlStore) TimeSeries(ctx context.Context, params models.FizzBuzzParams, granularity models.Granularity, since time.Time) (_ []models.FizzBuzzBucket, err error) {
+	statement := "SELECT `bucket`,`hits` FROM `stats_history` WHERE `params_hash` = ? AND `granularity` = ? AND `bucket` >= ? ORDER BY `bucket`"
+	ctx, end := s.startQuery(ctx, "timeseries", statement)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx, statement, params.Key(), string(granularity), granularity.Bucket(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query time series: %v", err)
+	}
+	defer rows.Close()
+
+	var buckets []models.FizzBuzzBucket
+	for rows.Next() {
+		var bucket models.FizzBuzzBucket
+		if err := rows.Scan(&bucket.Bucket, &bucket.Hits); err != nil {
+			return nil, fmt.Errorf("failed to scan time series: %v", err)
+		}
+		bucket.Bucket = bucket.Bucket.UTC()
+		buckets = append(buckets, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %v", err)
+	}
+
+	return buckets, nil
+}
+
+// scanStats reads and closes rows of start, end, step, rules, mode, hits and last_hit_at.
+func scanStats(rows *sql.Rows) ([]models.FizzBuzzStats, error) {
+	defer rows.Close()
+
+	var top []models.FizzBuzzStats
+	for rows.Next() {
+		var (
+			start, end, step, hits int
+			rules, mode            string
+			lastHitAt              time.Time
+		)
+		if err := rows.Scan(&start, &end, &step, &rules, &mode, &hits, &lastHitAt); err != nil {
+			return nil, fmt.Errorf("failed to scan most requested: %v", err)
+		}
+		stats := models.FizzBuzzStats{
+			Start: start,
+			End:   end,
+			Step:  step,
+			Mode:  models.Mode(mode),
+			Hits:  hits,
+
+			LastHitAt: lastHitAt,
+		}
+		if err := json.Unmarshal([]byte(rules), &stats.Rules); err != nil {
+			return nil, fmt.Errorf("failed to decode most requested rules: %v", err)
+		}
+		top = append(top, stats)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %v", err)
+	}
+
+	return top, nil
+}
+
+// startQuery starts the span of a query, holding its statement when it has a
+// single one. The returned function ends it once the query returned *err,
+// and observes the duration of the query.
+func (s *sqlStore) startQuery(ctx context.Context, query, statement string) (context.Context, func(err *error)) {
+	start := time.Now()
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system.name", string(s.dialect)),
+		attribute.String("db.operation.name", query),
+	}
+	if statement != "" {
+		attrs = append(attrs, attribute.String("db.query.text", statement))
+	}
+	ctx, span := s.tracer().Start(ctx, "stats "+query, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+
+	return ctx, func(err *error) {
+		status := "success"
+		if *err != nil {
+			status = "error"
+		}
+		prometheus.ObserveDBQuery(ctx, query, time.Since(start), status)
+		tracing.EndSpan(span, err)
+	}
+}
+
+// exec executes a statement writing to table in the transaction, in its own
+// span.
+func (s *sqlStore) exec(ctx context.Context, tx *sql.Tx, table, statement string, args ...any) (err error) {
+	ctx, span := s.tracer().Start(ctx, "INSERT "+table, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system.name", string(s.dialect)),
+		attribute.String("db.collection.name", table),
+		attribute.String("db.query.text", statement),
+	))
+	defer tracing.EndSpan(span, &err)
+
+	_, err = tx.ExecContext(ctx, statement, args...)
+	return err
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLite stores stats in a SQLite database, through a pure Go driver.
+type SQLite struct {
+	sqlStore
+}
+
+// OpenSQLite opens the SQLite database at path (":memory:" for a transient
+// one) and applies its pending migrations.
+func OpenSQLite(path string) (*SQLite, error) {
+	db, err := OpenSQLiteDB(path)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(db, DialectSQLite)
+	if err == nil {
+		_, err = migrator.Up(context.Background(), 0)
+	}
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate sqlite db: %v", err)
+	}
+
+	return NewSQLite(db), nil
+}
+
+// OpenSQLiteDB opens the SQLite database at path, as is.
+func OpenSQLiteDB(path string) (*sql.DB, error) {
+	// times are written in a format SQLite date functions understand, which
+	// also sorts chronologically
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	db, err := sql.Open("sqlite", path+separator+"_time_format=sqlite")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite db: %v", err)
+	}
+	// SQLite does not handle concurrent writes, and every connection to
+	// ":memory:" has its own database
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+func NewSQLite(db *sql.DB) *SQLite {
+	return &SQLite{
+		sqlStore: sqlStore{
+			db:              db,
+			dialect:         DialectSQLite,
+			incQuery:        "INSERT INTO `stats` (`params_hash`,`start`,`end`,`step`,`rules`,`mode`,`hits`,`last_hit_at`) VALUES (?,?,?,?,?,?,?,?) ON CONFLICT (`params_hash`) DO UPDATE SET `hits` = `hits`+excluded.`hits`, `last_hit_at` = max(`last_hit_at`, excluded.`last_hit_at`)",
+			incHistoryQuery: "INSERT INTO `stats_history` (`params_hash`,`granularity`,`bucket`,`hits`) VALUES (?,?,?,?),(?,?,?,?),(?,?,?,?) ON CONFLICT (`params_hash`,`granularity`,`bucket`) DO UPDATE SET `hits` = `hits`+excluded.`hits`",
+			binaryRules:     "`rules`",
+		},
+	}
+}
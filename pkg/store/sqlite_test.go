@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"test-lbc/pkg/models"
+	"test-lbc/prometheus"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSQLite(t *testing.T) {
+	s, err := OpenSQLite(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	testStore(t, s)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	prometheus.NewServer("", map[string]*sql.DB{"stats": s.DB()}).Handler.ServeHTTP(w, req)
+	for _, query := range []string{"inc_batch", "prune_history", "most_requested", "most_requested_since", "top", "timeseries"} {
+		metric := `fizzbuzz_db_query_duration_seconds_count{query="` + query + `",status="success"}`
+		if !strings.Contains(w.Body.String(), metric) {
+			t.Errorf("expected metric %s", metric)
+		}
+	}
+	if metric := `go_sql_max_open_connections{db_name="stats"} 1`; !strings.Contains(w.Body.String(), metric) {
+		t.Errorf("expected metric %s", metric)
+	}
+}
+
+func TestSQLite_Traces(t *testing.T) {
+	spans := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+
+	s, err := OpenSQLite(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+	s.tracerProvider = provider
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	if err := s.Inc(ctx, models.FizzBuzzParams{Start: 1, End: 15, Step: 1, Mode: models.ModeConcat}, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.MostRequested(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parent.End()
+
+	ended := spans.GetSpans()
+	names := map[string]tracetest.SpanStub{}
+	for _, span := range ended {
+		names[span.Name] = span
+	}
+	if len(ended) != 6 {
+		t.Fatalf("expected 6 spans, got %v", ended)
+	}
+	for name, parentName := range map[string]string{
+		"stats inc_batch":      "request",
+		"INSERT stats":         "stats inc_batch",
+		"INSERT stats_history": "stats inc_batch",
+		"stats prune_history":  "stats inc_batch",
+		"stats most_requested": "request",
+	} {
+		if names[name].Parent.SpanID() != names[parentName].SpanContext.SpanID() {
+			t.Errorf("expected span %q to be a child of %q, got %v", name, parentName, names[name])
+		}
+	}
+}
+
+func TestSQLite_Ready(t *testing.T) {
+	db, err := OpenSQLiteDB(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := NewSQLite(db)
+
+	migrator, err := NewMigrator(db, DialectSQLite)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := migrator.Up(context.Background(), 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if checks := s.Ready(context.Background()); len(checks) != 2 || checks[0].Status != models.HealthOK || checks[1].Status != models.HealthError {
+		t.Errorf("expected a pending migration, got %v", checks)
+	}
+
+	if _, err := migrator.Up(context.Background(), 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []models.HealthCheck{
+		{Name: "database", Status: models.HealthOK, Detail: "sqlite"},
+		{Name: "schema", Status: models.HealthOK, Detail: "version 3"},
+	}
+	if checks := s.Ready(context.Background()); !reflect.DeepEqual(checks, expected) {
+		t.Errorf("expected %v, got %v", expected, checks)
+	}
+
+	s.Close()
+	if checks := s.Ready(context.Background()); len(checks) != 1 || checks[0].Status != models.HealthError {
+		t.Errorf("expected the database to be down, got %v", checks)
+	}
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"test-lbc/pkg/models"
+	"testing"
+	"time"
+)
+
+type statsStore interface {
+	Inc(ctx context.Context, params models.FizzBuzzParams, at time.Time) error
+	IncBatch(ctx context.Context, hits []models.FizzBuzzHits) error
+	MostRequested(ctx context.Context) (*models.FizzBuzzStats, error)
+	Top(ctx context.Context, n int, tieBreak models.TieBreak) ([]models.FizzBuzzStats, error)
+	MostRequestedSince(ctx context.Context, granularity models.Granularity, since time.Time) (*models.FizzBuzzStats, error)
+	TimeSeries(ctx context.Context, params models.FizzBuzzParams, granularity models.Granularity, since time.Time) ([]models.FizzBuzzBucket, error)
+	Reset(ctx context.Context) error
+}
+
+// testStore runs the scenario every store must pass.
+func testStore(t *testing.T, s statsStore) {
+	mostRequested, err := s.MostRequested(context.Background())
+	if err != nil || mostRequested != nil {
+		t.Fatalf("expected no stats, got %v, %v", mostRequested, err)
+	}
+
+	var (
+		now     = time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+		classic = models.FizzBuzzParams{Start: 1, End: 100, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz"), Mode: models.ModeConcat}
+		product = models.FizzBuzzParams{Start: 1, End: 100, Step: 1, Rules: models.ClassicRules(3, 5, "fizz", "buzz"), Mode: models.ModeProduct}
+		window  = models.FizzBuzzParams{Start: 1000, End: 900, Step: -2, Rules: []models.Rule{{Divisor: 7, Word: "ba,zz"}}, Mode: models.ModeFirst}
+		other   = models.FizzBuzzParams{Start: 1, End: 10, Step: 1, Rules: []models.Rule{{Divisor: 8, Word: "eight"}}, Mode: models.ModeConcat}
+	)
+	for _, hit := range []struct {
+		params models.FizzBuzzParams
+		at     time.Time
+	}{
+		{classic, now},
+		{window, now.Add(time.Second)},
+		{classic, now.Add(2 * time.Second)},
+		{product, now.Add(3 * time.Second)},
+		{classic, now.Add(4 * time.Second)},
+		{other, now.Add(5 * time.Second)},
+		{window, now.Add(6 * time.Second)},
+		{other, now.Add(7 * time.Second)},
+	} {
+		if err := s.Inc(context.Background(), hit.params, hit.at); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var (
+		classicStats = models.FizzBuzzStats{Start: 1, End: 100, Step: 1, Rules: classic.Rules, Mode: models.ModeConcat, Hits: 3, LastHitAt: now.Add(4 * time.Second)}
+		windowStats  = models.FizzBuzzStats{Start: 1000, End: 900, Step: -2, Rules: window.Rules, Mode: models.ModeFirst, Hits: 2, LastHitAt: now.Add(6 * time.Second)}
+		otherStats   = models.FizzBuzzStats{Start: 1, End: 10, Step: 1, Rules: other.Rules, Mode: models.ModeConcat, Hits: 2, LastHitAt: now.Add(7 * time.Second)}
+	)
+
+	top, err := s.Top(context.Background(), 3, models.TieBreakRecent)
+	if expected := []models.FizzBuzzStats{classicStats, otherStats, windowStats}; err != nil || !equalStats(top, expected) {
+		t.Errorf("expected recent top %v, got %v, %v", expected, top, err)
+	}
+
+	top, err = s.Top(context.Background(), 3, models.TieBreakLexical)
+	if expected := []models.FizzBuzzStats{classicStats, windowStats, otherStats}; err != nil || !equalStats(top, expected) {
+		t.Errorf("expected lexical top %v, got %v, %v", expected, top, err)
+	}
+
+	if _, err := s.Top(context.Background(), 3, "random"); err == nil {
+		t.Errorf("expected an error on unknown tie-break, got nil")
+	}
+
+	mostRequested, err = s.MostRequested(context.Background())
+	if err != nil || mostRequested == nil || !equalStats([]models.FizzBuzzStats{*mostRequested}, []models.FizzBuzzStats{classicStats}) {
+		t.Errorf("expected most requested %v, got %v, %v", classicStats, mostRequested, err)
+	}
+
+	// an hour later, other is requested twice more and product once
+	later := now.Add(time.Hour)
+	for _, params := range []models.FizzBuzzParams{other, other, product} {
+		if err := s.Inc(context.Background(), params, later); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	otherStats.Hits, otherStats.LastHitAt = 2, later
+
+	mostRequested, err = s.MostRequestedSince(context.Background(), models.GranularityHour, later.Add(30*time.Minute))
+	if err != nil || mostRequested == nil || !equalStats([]models.FizzBuzzStats{*mostRequested}, []models.FizzBuzzStats{otherStats}) {
+		t.Errorf("expected most requested in the last hour %v, got %v, %v", otherStats, mostRequested, err)
+	}
+
+	mostRequested, err = s.MostRequestedSince(context.Background(), models.GranularityMinute, later.Add(time.Minute))
+	if err != nil || mostRequested != nil {
+		t.Errorf("expected no most requested in the future, got %v, %v", mostRequested, err)
+	}
+
+	series, err := s.TimeSeries(context.Background(), classic, models.GranularityHour, now.Add(-time.Hour))
+	if expected := []models.FizzBuzzBucket{{Bucket: now, Hits: 3}}; err != nil || !equalBuckets(series, expected) {
+		t.Errorf("expected classic hourly series %v, got %v, %v", expected, series, err)
+	}
+
+	series, err = s.TimeSeries(context.Background(), other, models.GranularityHour, now)
+	if expected := []models.FizzBuzzBucket{{Bucket: now, Hits: 2}, {Bucket: later, Hits: 2}}; err != nil || !equalBuckets(series, expected) {
+		t.Errorf("expected other hourly series %v, got %v, %v", expected, series, err)
+	}
+
+	series, err = s.TimeSeries(context.Background(), other, models.GranularityDay, now)
+	if expected := []models.FizzBuzzBucket{{Bucket: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), Hits: 4}}; err != nil || !equalBuckets(series, expected) {
+		t.Errorf("expected other daily series %v, got %v, %v", expected, series, err)
+	}
+
+	series, err = s.TimeSeries(context.Background(), models.FizzBuzzParams{Start: 1, End: 2, Step: 1}, models.GranularityMinute, now)
+	if err != nil || len(series) != 0 {
+		t.Errorf("expected no series of an unknown request, got %v, %v", series, err)
+	}
+
+	// a batch of coalesced hits, an older one not moving the last hit
+	err = s.IncBatch(context.Background(), []models.FizzBuzzHits{
+		{Params: window, Hits: 4, LastHitAt: later},
+		{Params: window, Hits: 3, LastHitAt: now.Add(30 * time.Second)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	windowStats.Hits, windowStats.LastHitAt = 9, later
+
+	mostRequested, err = s.MostRequested(context.Background())
+	if err != nil || mostRequested == nil || !equalStats([]models.FizzBuzzStats{*mostRequested}, []models.FizzBuzzStats{windowStats}) {
+		t.Errorf("expected most requested %v, got %v, %v", windowStats, mostRequested, err)
+	}
+
+	series, err = s.TimeSeries(context.Background(), window, models.GranularityMinute, now)
+	if expected := []models.FizzBuzzBucket{{Bucket: now, Hits: 5}, {Bucket: later, Hits: 4}}; err != nil || !equalBuckets(series, expected) {
+		t.Errorf("expected window series %v, got %v, %v", expected, series, err)
+	}
+
+	// a day later, the minute buckets of the first hour are expired
+	if err := s.Inc(context.Background(), other, later.Add(24*time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	series, err = s.TimeSeries(context.Background(), window, models.GranularityMinute, now)
+	if expected := []models.FizzBuzzBucket{{Bucket: later, Hits: 4}}; err != nil || !equalBuckets(series, expected) {
+		t.Errorf("expected pruned window series %v, got %v, %v", expected, series, err)
+	}
+
+	series, err = s.TimeSeries(context.Background(), window, models.GranularityHour, now)
+	if expected := []models.FizzBuzzBucket{{Bucket: now, Hits: 5}, {Bucket: later, Hits: 4}}; err != nil || !equalBuckets(series, expected) {
+		t.Errorf("expected hourly window series %v, got %v, %v", expected, series, err)
+	}
+
+	if err := s.Reset(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if top, err := s.Top(context.Background(), 3, models.TieBreakRecent); err != nil || len(top) != 0 {
+		t.Errorf("expected no stats once reset, got %v, %v", top, err)
+	}
+	series, err = s.TimeSeries(context.Background(), window, models.GranularityHour, now)
+	if err != nil || len(series) != 0 {
+		t.Errorf("expected no series once reset, got %v, %v", series, err)
+	}
+}
+
+// equalBuckets compares buckets, whatever the location of their times.
+func equalBuckets(a, b []models.FizzBuzzBucket) bool {
+	return slices.EqualFunc(a, b, func(a, b models.FizzBuzzBucket) bool {
+		return a.Bucket.Equal(b.Bucket) && a.Hits == b.Hits
+	})
+}
+
+// equalStats compares stats, whatever the location of their times.
+func equalStats(a, b []models.FizzBuzzStats) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].LastHitAt.Equal(b[i].LastHitAt) {
+			return false
+		}
+		a, b := a[i], b[i]
+		a.LastHitAt, b.LastHitAt = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(a, b) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -1,12 +1,16 @@
 package prometheus
 
 import (
-	"log"
+	"context"
+	"database/sql"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -14,11 +18,48 @@ var (
 		Name: "fizzbuzz_processed_ops_total",
 		Help: "The total number of processed events by status",
 	}, []string{"job", "status"})
+	statsQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "fizzbuzz_stats_queue_depth",
+		Help: "The number of coalesced stats increments waiting to be flushed",
+	})
+	statsFlushDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fizzbuzz_stats_flush_duration_seconds",
+		Help:    "The duration of the stats flushes by status",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"status"})
+	rateLimitedCounterVec = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fizzbuzz_rate_limited_requests_total",
+		Help: "The total number of requests rejected by the rate limiter by route",
+	}, []string{"route"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fizzbuzz_http_request_duration_seconds",
+		Help:    "The duration of the http requests by route, method and status code",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+	responseSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fizzbuzz_http_response_size_bytes",
+		Help:    "The size of the http response bodies by route",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 10), // 64B to 16MiB
+	}, []string{"route"})
+	requestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "fizzbuzz_http_requests_in_flight",
+		Help: "The number of http requests being served",
+	})
+	requestedValues = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fizzbuzz_requested_values",
+		Help:    "The number of values of the requested sequences by job",
+		Buckets: prometheus.ExponentialBuckets(1, 10, 10), // 1 to 10^9
+	}, []string{"job"})
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fizzbuzz_db_query_duration_seconds",
+		Help:    "The duration of the stats database queries by query and status",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"query", "status"})
 )
 
-func Start(prometheusBindAddr string) {
-	log.Printf("start prometheus server on port %s", prometheusBindAddr)
-
+// NewServer returns a server exposing the metrics on prometheusBindAddr,
+// along with the connection pool stats of dbs by name.
+func NewServer(prometheusBindAddr string, dbs map[string]*sql.DB) *http.Server {
 	reg := prometheus.NewRegistry()
 	reg.MustRegister(
 		// default collectors
@@ -26,10 +67,29 @@ func Start(prometheusBindAddr string) {
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 
 		counterVec,
+		statsQueueDepth,
+		statsFlushDuration,
+		rateLimitedCounterVec,
+		requestDuration,
+		responseSize,
+		requestsInFlight,
+		requestedValues,
+		dbQueryDuration,
 	)
+	for name, db := range dbs {
+		reg.MustRegister(collectors.NewDBStatsCollector(db, name))
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{
+		// exemplars are only exposed in the OpenMetrics format
+		EnableOpenMetrics: true,
+	}))
 
-	http.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
-	http.ListenAndServe(prometheusBindAddr, nil)
+	return &http.Server{
+		Addr:    prometheusBindAddr,
+		Handler: mux,
+	}
 }
 
 // Increment total counter of job requested
@@ -41,3 +101,50 @@ func IncRequest(job string) {
 func IncStats(job, status string) {
 	counterVec.WithLabelValues(job, status).Inc()
 }
+
+// Set the number of stats increments waiting to be flushed
+func SetStatsQueueDepth(depth int) {
+	statsQueueDepth.Set(float64(depth))
+}
+
+// Observe the duration of a stats flush by status (e.g "success", "error"...)
+func ObserveStatsFlush(duration time.Duration, status string) {
+	statsFlushDuration.WithLabelValues(status).Observe(duration.Seconds())
+}
+
+// Increment total counter of requests to route rejected by the rate limiter
+func IncRateLimited(route string) {
+	rateLimitedCounterVec.WithLabelValues(route).Inc()
+}
+
+// Observe the duration and response size of a http request to route
+func ObserveRequest(ctx context.Context, route, method string, status int, duration time.Duration, size int) {
+	observe(ctx, requestDuration.WithLabelValues(route, method, strconv.Itoa(status)), duration.Seconds())
+	responseSize.WithLabelValues(route).Observe(float64(size))
+}
+
+// Add delta to the number of http requests being served
+func AddRequestsInFlight(delta int) {
+	requestsInFlight.Add(float64(delta))
+}
+
+// Observe the number of values of a sequence requested to job
+func ObserveRequestedValues(job string, values int) {
+	requestedValues.WithLabelValues(job).Observe(float64(values))
+}
+
+// Observe the duration of a stats database query by status (e.g "success", "error"...)
+func ObserveDBQuery(ctx context.Context, query string, duration time.Duration, status string) {
+	observe(ctx, dbQueryDuration.WithLabelValues(query, status), duration.Seconds())
+}
+
+// observe observes value, with the ID of the trace of ctx as exemplar when
+// the trace is sampled, so that a dashboard links to it.
+func observe(ctx context.Context, observer prometheus.Observer, value float64) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && spanContext.IsSampled() {
+		exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{"trace_id": spanContext.TraceID().String()})
+		return
+	}
+	observer.Observe(value)
+}
@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Exporters are the supported span exporters.
+var Exporters = []string{ExporterNone, ExporterStdout, ExporterOTLP}
+
+// ServiceName is the name of the service in the traces, unless the
+// OTEL_SERVICE_NAME environment variable sets another one.
+const ServiceName = "fizzbuzz-service"
+
+// Config is the configuration of the tracing.
+type Config struct {
+	// Exporter is where the spans are exported, one of Exporters
+	Exporter string
+	// Endpoint is the address of the OTLP gRPC collector
+	Endpoint string
+	// SampleRatio is the ratio of the traces recorded, when the caller did
+	// not decide whether its trace is
+	SampleRatio float64
+	// Writer is where the stdout exporter writes, os.Stdout when nil
+	Writer io.Writer
+}
+
+// Setup propagates the W3C trace context of the callers, and records the
+// spans to the exporter of config. It returns the function flushing the
+// recorded spans and releasing the exporter.
+//
+// With ExporterNone, no span is recorded, but the trace of a caller still
+// flows through the service: its ID is logged, and exposed in the metrics.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return nil, fmt.Errorf("trace sample ratio must be between 0 and 1, got %v", config.SampleRatio)
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch config.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		w := config.Writer
+		if w == nil {
+			w = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	case ExporterOTLP:
+		exporter, err = otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(config.Endpoint), otlptracegrpc.WithInsecure())
+	default:
+		return nil, fmt.Errorf("trace exporter must be one of %v", Exporters)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %v", config.Exporter, err)
+	}
+
+	// the environment overrides the default service name
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(attribute.String("service.name", ServiceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the service: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// EndSpan ends span, as failed when *err is set once the traced function
+// returned.
+func EndSpan(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup(t *testing.T) {
+	var buf bytes.Buffer
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterStdout, SampleRatio: 1, Writer: &buf})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	func() (err error) {
+		_, span := otel.Tracer("test").Start(context.Background(), "failing")
+		defer EndSpan(span, &err)
+		return errors.New("boom")
+	}()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{`"Name":"failing"`, `"Description":"boom"`, `"Value":"fizzbuzz-service"`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected the exported span to hold %s, got %s", expected, buf.String())
+		}
+	}
+
+	if _, err := Setup(context.Background(), Config{Exporter: "zipkin"}); err == nil {
+		t.Errorf("expected an error on unknown exporter, got nil")
+	}
+	if _, err := Setup(context.Background(), Config{Exporter: ExporterStdout, SampleRatio: 2}); err == nil {
+		t.Errorf("expected an error on invalid sample ratio, got nil")
+	}
+}